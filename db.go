@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 	"sync/atomic"
 
 	"github.com/uptrace/bun/dialect/feature"
@@ -17,6 +18,8 @@ import (
 
 const (
 	discardUnknownColumns internal.Flag = 1 << iota
+	includeDeletedFlag
+	debugChecksFlag
 )
 
 type DBStats struct {
@@ -32,6 +35,30 @@ func WithDiscardUnknownColumns() DBOption {
 	}
 }
 
+// WithDebugChecks enables extra validation of raw SQL expressions passed to
+// ColumnExpr, Where, Set and similar methods. Currently it reports
+// unbalanced parentheses outside of string literals and quoted identifiers.
+func WithDebugChecks() DBOption {
+	return func(db *DB) {
+		db.flags = db.flags.Set(debugChecksFlag)
+	}
+}
+
+// EmptyInPolicy controls how bun.In with an empty slice and WherePK
+// with an empty slice are rendered.
+type EmptyInPolicy = schema.EmptyInPolicy
+
+const (
+	// EmptyInAsIs renders the empty list as is, for example, `id IN ()`.
+	EmptyInAsIs = schema.EmptyInAsIs
+	// EmptyInError renders an error instead of the list, so the query fails.
+	EmptyInError = schema.EmptyInError
+	// EmptyInFalseLiteral renders the empty list as NULL, so `id IN (?)`
+	// matches no rows, and WherePK as 1 = 0. `id NOT IN (?)` fails with
+	// an error, because it would not match any rows either.
+	EmptyInFalseLiteral = schema.EmptyInFalseLiteral
+)
+
 type DB struct {
 	*sql.DB
 
@@ -40,8 +67,13 @@ type DB struct {
 
 	queryHooks []QueryHook
 
-	fmter schema.Formatter
-	flags internal.Flag
+	fmter         schema.Formatter
+	flags         internal.Flag
+	emptyInPolicy EmptyInPolicy
+	schemaName    string
+	tablePrefix   string
+	replicas      *replicaSet
+	queryCache    *queryCache
 
 	stats DBStats
 }
@@ -54,6 +86,7 @@ func NewDB(sqldb *sql.DB, dialect schema.Dialect, opts ...DBOption) *DB {
 		dialect:  dialect,
 		features: dialect.Features(),
 		fmter:    schema.NewFormatter(dialect),
+		replicas: new(replicaSet),
 	}
 
 	for _, opt := range opts {
@@ -102,6 +135,10 @@ func (db *DB) NewCreateTable() *CreateTableQuery {
 	return NewCreateTableQuery(db)
 }
 
+func (db *DB) NewCreateTableAs(name string, query schema.QueryAppender) *CreateTableAsQuery {
+	return NewCreateTableAsQuery(db).Table(name).As(query)
+}
+
 func (db *DB) NewDropTable() *DropTableQuery {
 	return NewDropTableQuery(db)
 }
@@ -114,6 +151,10 @@ func (db *DB) NewDropIndex() *DropIndexQuery {
 	return NewDropIndexQuery(db)
 }
 
+func (db *DB) NewRenameIndex() *RenameIndexQuery {
+	return NewRenameIndexQuery(db)
+}
+
 func (db *DB) NewTruncateTable() *TruncateTableQuery {
 	return NewTruncateTableQuery(db)
 }
@@ -126,6 +167,14 @@ func (db *DB) NewDropColumn() *DropColumnQuery {
 	return NewDropColumnQuery(db)
 }
 
+func (db *DB) NewAlterColumn() *AlterColumnQuery {
+	return NewAlterColumnQuery(db)
+}
+
+func (db *DB) NewAddUniqueConstraint() *AddUniqueConstraintQuery {
+	return NewAddUniqueConstraintQuery(db)
+}
+
 func (db *DB) ResetModel(ctx context.Context, models ...interface{}) error {
 	for _, model := range models {
 		if _, err := db.NewDropTable().Model(model).IfExists().Cascade().Exec(ctx); err != nil {
@@ -142,11 +191,45 @@ func (db *DB) Dialect() schema.Dialect {
 	return db.dialect
 }
 
+// Indexes returns indexes that exist on the table, including the primary key index.
+// It requires the dialect to implement schema.IndexInspector.
+func (db *DB) Indexes(ctx context.Context, table string) ([]schema.IndexInfo, error) {
+	inspector, ok := db.dialect.(schema.IndexInspector)
+	if !ok {
+		return nil, fmt.Errorf("bun: %s does not support listing indexes", db.dialect.Name())
+	}
+
+	rows, err := db.QueryContext(ctx, inspector.IndexesQuery(), table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []schema.IndexInfo
+	for rows.Next() {
+		var name string
+		var column sql.NullString
+		var unique bool
+		if err := rows.Scan(&name, &unique, &column); err != nil {
+			return nil, err
+		}
+
+		if n := len(indexes); n == 0 || indexes[n-1].Name != name {
+			indexes = append(indexes, schema.IndexInfo{Name: name, Unique: unique})
+		}
+		index := &indexes[len(indexes)-1]
+		index.Columns = append(index.Columns, column.String)
+	}
+
+	return indexes, rows.Err()
+}
+
 func (db *DB) ScanRows(ctx context.Context, rows *sql.Rows, dest ...interface{}) error {
 	defer rows.Close()
 
 	model, err := newModel(db, dest)
 	if err != nil {
+		closeChanDest(dest)
 		return err
 	}
 
@@ -161,11 +244,13 @@ func (db *DB) ScanRows(ctx context.Context, rows *sql.Rows, dest ...interface{})
 func (db *DB) ScanRow(ctx context.Context, rows *sql.Rows, dest ...interface{}) error {
 	model, err := newModel(db, dest)
 	if err != nil {
+		closeChanDest(dest)
 		return err
 	}
 
 	rs, ok := model.(rowScanner)
 	if !ok {
+		closeChanModel(model)
 		return fmt.Errorf("bun: %T does not support ScanRow", model)
 	}
 
@@ -194,6 +279,15 @@ func (db *DB) RegisterModel(models ...interface{}) {
 }
 
 func (db *DB) clone() *DB {
+	clone := db.shallowClone()
+	clone.replicas = clone.replicas.clone()
+	clone.queryCache = clone.queryCache.clone()
+	return clone
+}
+
+// shallowClone returns a copy of the DB that shares the replicas
+// and the query cache with the original.
+func (db *DB) shallowClone() *DB {
 	clone := *db
 
 	l := len(clone.queryHooks)
@@ -208,6 +302,146 @@ func (db *DB) WithNamedArg(name string, value interface{}) *DB {
 	return clone
 }
 
+// WithEmptyInPolicy returns a copy of the DB that renders conditions
+// with empty IN lists according to the policy.
+func (db *DB) WithEmptyInPolicy(policy EmptyInPolicy) *DB {
+	clone := db.clone()
+	clone.emptyInPolicy = policy
+	return clone
+}
+
+// WithSchema returns a copy of the DB that qualifies unqualified table names
+// with the schema, for example, "tenant1"."users".
+func (db *DB) WithSchema(name string) *DB {
+	clone := db.clone()
+	clone.schemaName = name
+	return clone
+}
+
+// WithTablePrefix returns a copy of the DB that prefixes model table names
+// and tables added with Table, for example, "test_users". Tables that reference
+// a CTE are not prefixed.
+func (db *DB) WithTablePrefix(prefix string) *DB {
+	clone := db.clone()
+	clone.tablePrefix = prefix
+	return clone
+}
+
+// WithQueryCache returns a copy of the DB that caches up to size select
+// queries by structure, for example, the WHERE and ORDER BY clauses without
+// the argument values. Executing a query that is built the same way only
+// appends the argument values to the cached query. Queries with relations,
+// CTEs, unions, subqueries, In, or WherePK are formatted as usual.
+// Copies of the returned DB start with an empty cache.
+func (db *DB) WithQueryCache(size int) *DB {
+	clone := db.clone()
+	clone.queryCache = newQueryCache(size)
+	return clone
+}
+
+// addTablePrefix prefixes the last component of the possibly qualified table name.
+func (db *DB) addTablePrefix(table string) string {
+	if db.tablePrefix == "" {
+		return table
+	}
+	i := strings.LastIndexByte(table, '.') + 1
+	return table[:i] + db.tablePrefix + table[i:]
+}
+
+type includeDeletedKey struct{}
+
+// WithIncludeDeleted returns a copy of the context that makes queries
+// created by DB.WithContextFlags include soft deleted rows.
+func WithIncludeDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeDeletedKey{}, true)
+}
+
+// WithContextFlags returns a copy of the DB that applies the flags stored
+// in the context, for example, by WithIncludeDeleted, to select, update,
+// and delete queries created with the returned DB. It is meant to be called
+// once per request, so the copy shares the replicas and the query cache
+// with the original DB.
+func (db *DB) WithContextFlags(ctx context.Context) *DB {
+	clone := db.shallowClone()
+	if v, _ := ctx.Value(includeDeletedKey{}).(bool); v {
+		clone.flags = clone.flags.Set(includeDeletedFlag)
+	} else {
+		clone.flags = clone.flags.Remove(includeDeletedFlag)
+	}
+	return clone
+}
+
+type fetchSizeKey struct{}
+
+// WithFetchSize returns a copy of the context that asks the connection to fetch
+// n rows per round trip instead of buffering the whole result set.
+// It is set by SelectQuery.FetchSize.
+func WithFetchSize(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, fetchSizeKey{}, n)
+}
+
+// FetchSizeFromContext returns the fetch size set by WithFetchSize.
+// database/sql has no fetch size option, so the value is meant for drivers
+// and IConn wrappers that implement it with a server-side cursor.
+// pgdriver fetches n rows at a time from a portal; pgx and lib/pq don't read it.
+func FetchSizeFromContext(ctx context.Context) (int, bool) {
+	n, ok := ctx.Value(fetchSizeKey{}).(int)
+	return n, ok
+}
+
+// queryFlags returns the initial flags of select, update, and delete queries.
+func (db *DB) queryFlags() internal.Flag {
+	var flags internal.Flag
+	if db.flags.Has(includeDeletedFlag) {
+		flags = flags.Set(allWithDeletedFlag)
+	}
+	return flags
+}
+
+type replicaSet struct {
+	mu    sync.RWMutex
+	conns []IConn
+	next  uint32
+}
+
+func (rs *replicaSet) clone() *replicaSet {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	return &replicaSet{
+		conns: append([]IConn(nil), rs.conns...),
+	}
+}
+
+func (rs *replicaSet) add(conn IConn) {
+	rs.mu.Lock()
+	rs.conns = append(rs.conns, conn)
+	rs.mu.Unlock()
+}
+
+// conn returns the next replica or nil if there are no replicas.
+func (rs *replicaSet) conn() IConn {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	if len(rs.conns) == 0 {
+		return nil
+	}
+	n := atomic.AddUint32(&rs.next, 1)
+	return rs.conns[(n-1)%uint32(len(rs.conns))]
+}
+
+// AddReplica registers a read replica. Select queries with UseReplica are
+// distributed between the replicas in round-robin order; all other queries
+// use the primary database. Copies of the DB, for example, created with
+// WithNamedArg, keep the replicas added so far, but don't share new ones.
+func (db *DB) AddReplica(replica IConn) {
+	if bunDB, ok := replica.(*DB); ok {
+		replica = bunDB.DB
+	}
+	db.replicas.add(replica)
+}
+
 func (db *DB) Formatter() schema.Formatter {
 	return db.fmter
 }
@@ -346,6 +580,10 @@ func (c Conn) NewCreateTable() *CreateTableQuery {
 	return NewCreateTableQuery(c.db).Conn(c)
 }
 
+func (c Conn) NewCreateTableAs(name string, query schema.QueryAppender) *CreateTableAsQuery {
+	return NewCreateTableAsQuery(c.db).Conn(c).Table(name).As(query)
+}
+
 func (c Conn) NewDropTable() *DropTableQuery {
 	return NewDropTableQuery(c.db).Conn(c)
 }
@@ -358,6 +596,10 @@ func (c Conn) NewDropIndex() *DropIndexQuery {
 	return NewDropIndexQuery(c.db).Conn(c)
 }
 
+func (c Conn) NewRenameIndex() *RenameIndexQuery {
+	return NewRenameIndexQuery(c.db).Conn(c)
+}
+
 func (c Conn) NewTruncateTable() *TruncateTableQuery {
 	return NewTruncateTableQuery(c.db).Conn(c)
 }
@@ -370,6 +612,14 @@ func (c Conn) NewDropColumn() *DropColumnQuery {
 	return NewDropColumnQuery(c.db).Conn(c)
 }
 
+func (c Conn) NewAlterColumn() *AlterColumnQuery {
+	return NewAlterColumnQuery(c.db).Conn(c)
+}
+
+func (c Conn) NewAddUniqueConstraint() *AddUniqueConstraintQuery {
+	return NewAddUniqueConstraintQuery(c.db).Conn(c)
+}
+
 // RunInTx runs the function in a transaction. If the function returns an error,
 // the transaction is rolled back. Otherwise, the transaction is committed.
 func (c Conn) RunInTx(
@@ -624,6 +874,30 @@ func (tx Tx) RunInTx(
 	return sp.Commit()
 }
 
+// DeferConstraints defers checking of the named constraints until the transaction
+// is committed. When no names are given, all deferrable constraints are deferred.
+// Only PostgreSQL supports SET CONSTRAINTS.
+func (tx Tx) DeferConstraints(ctx context.Context, names ...string) error {
+	if !tx.db.features.Has(feature.DeferrableConstraint) {
+		return fmt.Errorf("bun: %s does not support SET CONSTRAINTS", tx.db.dialect.Name())
+	}
+
+	b := []byte("SET CONSTRAINTS ")
+	if len(names) == 0 {
+		b = append(b, "ALL"...)
+	}
+	for i, name := range names {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		b = tx.db.fmter.AppendIdent(b, name)
+	}
+	b = append(b, " DEFERRED"...)
+
+	_, err := tx.ExecContext(ctx, internal.String(b))
+	return err
+}
+
 func (tx Tx) Dialect() schema.Dialect {
 	return tx.db.Dialect()
 }
@@ -652,6 +926,10 @@ func (tx Tx) NewCreateTable() *CreateTableQuery {
 	return NewCreateTableQuery(tx.db).Conn(tx)
 }
 
+func (tx Tx) NewCreateTableAs(name string, query schema.QueryAppender) *CreateTableAsQuery {
+	return NewCreateTableAsQuery(tx.db).Conn(tx).Table(name).As(query)
+}
+
 func (tx Tx) NewDropTable() *DropTableQuery {
 	return NewDropTableQuery(tx.db).Conn(tx)
 }
@@ -664,6 +942,10 @@ func (tx Tx) NewDropIndex() *DropIndexQuery {
 	return NewDropIndexQuery(tx.db).Conn(tx)
 }
 
+func (tx Tx) NewRenameIndex() *RenameIndexQuery {
+	return NewRenameIndexQuery(tx.db).Conn(tx)
+}
+
 func (tx Tx) NewTruncateTable() *TruncateTableQuery {
 	return NewTruncateTableQuery(tx.db).Conn(tx)
 }
@@ -676,6 +958,14 @@ func (tx Tx) NewDropColumn() *DropColumnQuery {
 	return NewDropColumnQuery(tx.db).Conn(tx)
 }
 
+func (tx Tx) NewAlterColumn() *AlterColumnQuery {
+	return NewAlterColumnQuery(tx.db).Conn(tx)
+}
+
+func (tx Tx) NewAddUniqueConstraint() *AddUniqueConstraintQuery {
+	return NewAddUniqueConstraintQuery(tx.db).Conn(tx)
+}
+
 //------------------------------------------------------------------------------
 
 func (db *DB) makeQueryBytes() []byte {
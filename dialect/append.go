@@ -86,3 +86,28 @@ loop:
 	}
 	return b
 }
+
+//------------------------------------------------------------------------------
+
+// AppendJSONPath appends a MySQL and SQLite JSON path, for example, $."a"[0].
+// Numeric path elements index arrays.
+func AppendJSONPath(b []byte, path []string) []byte {
+	b = append(b, '$')
+	for _, key := range path {
+		if _, err := strconv.ParseUint(key, 10, 64); err == nil {
+			b = append(b, '[')
+			b = append(b, key...)
+			b = append(b, ']')
+			continue
+		}
+		b = append(b, '.', '"')
+		for i := 0; i < len(key); i++ {
+			if c := key[i]; c == '"' || c == '\\' {
+				b = append(b, '\\')
+			}
+			b = append(b, key[i])
+		}
+		b = append(b, '"')
+	}
+	return b
+}
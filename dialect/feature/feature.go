@@ -31,4 +31,38 @@ const (
 	UpdateFromTable
 	MSSavepoint
 	GeneratedIdentity
+	RenameColumn         // ALTER TABLE ... RENAME COLUMN
+	AutoIncrementPK      // INTEGER PRIMARY KEY AUTOINCREMENT
+	TempTableOnCommit    // CREATE TEMP TABLE ... ON COMMIT
+	IndexStorageParams   // CREATE INDEX ... WITH (fillfactor = ...)
+	InValues             // IN (VALUES ...)
+	SelectInto           // SELECT ... INTO
+	DeferrableConstraint // DEFERRABLE and SET CONSTRAINTS
+	TableInheritance     // SELECT ... FROM ONLY
+	DoBlock              // DO $$ BEGIN ... END $$
+	PostGIS              // ST_DWithin
+	Trigram              // pg_trgm similarity
+	ReturnInserted       // RETURNING (xmax = 0) AS inserted
+	TableSample          // SELECT ... TABLESAMPLE
+	LimitAll             // LIMIT ALL
+	ExplainOptions       // EXPLAIN (ANALYZE, BUFFERS, VERBOSE, FORMAT ...)
+	IndexComment         // COMMENT ON INDEX
+	AlterIndexRename     // ALTER INDEX ... RENAME TO
+	WithOrdinality       // WITH ORDINALITY
+	AggFilter            // count(*) FILTER (WHERE ...)
+	SubqueryQuantifier   // = ANY (SELECT ...) and > ALL (SELECT ...)
+	QuotedCollation      // COLLATE "name"
+	RowValues            // (a, b) > (1, 2)
+	BoolType             // TRUE and IS [NOT] TRUE
+	CastAsSigned         // CAST(... AS SIGNED) and CAST(... AS CHAR)
+	AlterColumnDefault   // ALTER TABLE ... ALTER COLUMN ... SET DEFAULT
+	ChangeColumn         // ALTER TABLE ... CHANGE old new type
+	SpRename             // EXEC sp_rename
+	AddConstraint        // ALTER TABLE ... ADD CONSTRAINT
+	InlineIndexComment   // CREATE INDEX ... COMMENT '...'
+	DropIndexOnTable     // DROP INDEX name ON table
+	RenameIndexOnTable   // ALTER TABLE ... RENAME INDEX
+	SelectIntoTemp       // SELECT ... INTO TEMP
+	CreateTableAs        // CREATE TABLE ... AS SELECT
+	CreateTableAsNoData  // CREATE TABLE ... AS SELECT ... WITH NO DATA
 )
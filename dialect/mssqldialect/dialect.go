@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 	"time"
 
@@ -47,7 +48,12 @@ func New() *Dialect {
 		feature.Output |
 		feature.OffsetFetch |
 		feature.UpdateFromTable |
-		feature.MSSavepoint
+		feature.MSSavepoint |
+		feature.SelectInto |
+		feature.SubqueryQuantifier |
+		feature.SpRename |
+		feature.AddConstraint |
+		feature.DropIndexOnTable
 	return d
 }
 
@@ -95,6 +101,33 @@ func (d *Dialect) IdentQuote() byte {
 	return '"'
 }
 
+// IndexesQuery implements schema.IndexInspector.
+func (d *Dialect) IndexesQuery() string {
+	return `SELECT i.name, i.is_unique, c.name
+FROM sys.indexes AS i
+JOIN sys.index_columns AS ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id
+JOIN sys.columns AS c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+WHERE i.object_id = OBJECT_ID(?) AND ic.is_included_column = 0
+ORDER BY i.name, ic.key_ordinal`
+}
+
+// MaxIdentLen implements schema.IdentLimiter.
+func (*Dialect) MaxIdentLen() int {
+	return 128
+}
+
+// AppendCurrentTimestamp implements schema.CurrentTimestampAppender.
+// CURRENT_TIMESTAMP returns the local time of the server.
+func (*Dialect) AppendCurrentTimestamp(b []byte) []byte {
+	return append(b, "SYSUTCDATETIME()"...)
+}
+
+// AppendPlaceholder implements schema.PlaceholderAppender.
+func (*Dialect) AppendPlaceholder(b []byte, n int) []byte {
+	b = append(b, "@p"...)
+	return strconv.AppendInt(b, int64(n), 10)
+}
+
 func (*Dialect) AppendTime(b []byte, tm time.Time) []byte {
 	b = append(b, '\'')
 	b = tm.AppendFormat(b, "2006-01-02 15:04:05.999")
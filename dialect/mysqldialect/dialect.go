@@ -45,7 +45,18 @@ func New() *Dialect {
 		feature.TableNotExists |
 		feature.InsertIgnore |
 		feature.InsertOnDuplicateKey |
-		feature.SelectExists
+		feature.SelectExists |
+		feature.SubqueryQuantifier |
+		feature.RowValues |
+		feature.BoolType |
+		feature.CastAsSigned |
+		feature.AlterColumnDefault |
+		feature.ChangeColumn |
+		feature.AddConstraint |
+		feature.InlineIndexComment |
+		feature.DropIndexOnTable |
+		feature.RenameIndexOnTable |
+		feature.CreateTableAs
 	return d
 }
 
@@ -59,14 +70,15 @@ func (d *Dialect) Init(db *sql.DB) {
 	if strings.Contains(version, "MariaDB") {
 		version = semver.MajorMinor("v" + cleanupVersion(version))
 		if semver.Compare(version, "v10.5.0") >= 0 {
-			d.features |= feature.InsertReturning
+			d.features |= feature.InsertReturning | feature.RenameColumn
 		}
 		return
 	}
 
 	version = semver.MajorMinor("v" + cleanupVersion(version))
 	if semver.Compare(version, "v8.0") >= 0 {
-		d.features |= feature.CTE | feature.WithValues | feature.DeleteTableAlias
+		d.features |= feature.CTE | feature.WithValues | feature.DeleteTableAlias |
+			feature.RenameColumn
 	}
 }
 
@@ -99,6 +111,44 @@ func (d *Dialect) IdentQuote() byte {
 	return '`'
 }
 
+// IndexesQuery implements schema.IndexInspector.
+func (d *Dialect) IndexesQuery() string {
+	return `SELECT index_name, non_unique = 0, column_name
+FROM information_schema.statistics
+WHERE table_schema = DATABASE() AND table_name = ?
+ORDER BY index_name, seq_in_index`
+}
+
+// MaxIdentLen implements schema.IdentLimiter.
+func (*Dialect) MaxIdentLen() int {
+	return 64
+}
+
+// AppendCurrentTimestamp implements schema.CurrentTimestampAppender.
+// CURRENT_TIMESTAMP returns the time in the session time zone.
+func (*Dialect) AppendCurrentTimestamp(b []byte) []byte {
+	return append(b, "UTC_TIMESTAMP()"...)
+}
+
+// AppendNoLimit implements schema.NoLimitAppender.
+func (*Dialect) AppendNoLimit(b []byte) []byte {
+	return append(b, " LIMIT 18446744073709551615"...)
+}
+
+// AppendJSONSet implements schema.JSONSetAppender.
+func (d *Dialect) AppendJSONSet(
+	fmter schema.Formatter, b []byte, column string, path []string, value string,
+) []byte {
+	b = append(b, "JSON_SET("...)
+	b = fmter.AppendIdent(b, column)
+	b = append(b, ", "...)
+	b = d.AppendString(b, string(dialect.AppendJSONPath(nil, path)))
+	b = append(b, ", JSON_EXTRACT("...)
+	b = d.AppendString(b, value)
+	b = append(b, ", '$'))"...)
+	return b
+}
+
 func (*Dialect) AppendTime(b []byte, tm time.Time) []byte {
 	b = append(b, '\'')
 	b = tm.AppendFormat(b, "2006-01-02 15:04:05.999999")
@@ -47,7 +47,34 @@ func New() *Dialect {
 		feature.TableNotExists |
 		feature.InsertOnConflict |
 		feature.SelectExists |
-		feature.GeneratedIdentity
+		feature.GeneratedIdentity |
+		feature.RenameColumn |
+		feature.TempTableOnCommit |
+		feature.IndexStorageParams |
+		feature.InValues |
+		feature.SelectInto |
+		feature.DeferrableConstraint |
+		feature.TableInheritance |
+		feature.DoBlock |
+		feature.PostGIS |
+		feature.Trigram |
+		feature.ReturnInserted |
+		feature.TableSample |
+		feature.LimitAll |
+		feature.ExplainOptions |
+		feature.IndexComment |
+		feature.AlterIndexRename |
+		feature.WithOrdinality |
+		feature.AggFilter |
+		feature.SubqueryQuantifier |
+		feature.QuotedCollation |
+		feature.RowValues |
+		feature.BoolType |
+		feature.AlterColumnDefault |
+		feature.AddConstraint |
+		feature.SelectIntoTemp |
+		feature.CreateTableAs |
+		feature.CreateTableAsNoData
 	return d
 }
 
@@ -100,6 +127,46 @@ func (d *Dialect) IdentQuote() byte {
 	return '"'
 }
 
+// IndexesQuery implements schema.IndexInspector.
+// Expression index columns have no attribute, so the expression is
+// selected instead, for example, lower(name).
+func (d *Dialect) IndexesQuery() string {
+	return `SELECT i.relname, ix.indisunique,
+  COALESCE(a.attname, pg_get_indexdef(ix.indexrelid, k.n::int, true))
+FROM pg_index AS ix
+JOIN pg_class AS i ON i.oid = ix.indexrelid
+CROSS JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, n)
+LEFT JOIN pg_attribute AS a ON a.attrelid = ix.indrelid AND a.attnum = k.attnum
+WHERE ix.indrelid = to_regclass(?)
+ORDER BY i.relname, k.n`
+}
+
+// MaxIdentLen implements schema.IdentLimiter.
+// PostgreSQL truncates identifiers to 63 bytes.
+func (d *Dialect) MaxIdentLen() int {
+	return 63
+}
+
+// AppendPlaceholder implements schema.PlaceholderAppender.
+func (d *Dialect) AppendPlaceholder(b []byte, n int) []byte {
+	b = append(b, '$')
+	return strconv.AppendInt(b, int64(n), 10)
+}
+
+// AppendJSONSet implements schema.JSONSetAppender.
+func (d *Dialect) AppendJSONSet(
+	fmter schema.Formatter, b []byte, column string, path []string, value string,
+) []byte {
+	b = append(b, "jsonb_set("...)
+	b = fmter.AppendIdent(b, column)
+	b = append(b, ", "...)
+	b = appendStringSlice(b, path)
+	b = append(b, ", "...)
+	b = d.AppendString(b, value)
+	b = append(b, "::jsonb)"...)
+	return b
+}
+
 func (d *Dialect) AppendUint32(b []byte, n uint32) []byte {
 	return strconv.AppendInt(b, int64(int32(n)), 10)
 }
@@ -83,6 +83,10 @@ func (p *hstoreParser) NextValue() (string, error) {
 			p.skipNext()
 		}
 
+		if p.peek() == ' ' {
+			p.skipNext()
+		}
+
 		return string(value), nil
 	}
 }
@@ -17,6 +17,8 @@ func TestHStoreParser(t *testing.T) {
 
 		{`"1"=>"2", "3"=>"4"`, map[string]string{"1": "2", "3": "4"}},
 		{`"1"=>NULL`, map[string]string{"1": ""}},
+		{`"1"=>NULL, "2"=>"3"`, map[string]string{"1": "", "2": "3"}},
+		{`"a\"b"=>"c\"d", "e"=>NULL`, map[string]string{`a"b`: `c"d`, "e": ""}},
 		{`"1"=>"NULL"`, map[string]string{"1": "NULL"}},
 		{`"{1}"=>"{2}", "{3}"=>"{4}"`, map[string]string{"{1}": "{2}", "{3}": "{4}"}},
 	}
@@ -56,6 +56,9 @@ func decodeMapStringString(src interface{}) (map[string]string, error) {
 	}
 
 	m := make(map[string]string)
+	if len(b) == 0 {
+		return m, nil
+	}
 
 	p := newHStoreParser(b)
 	for {
@@ -0,0 +1,206 @@
+package pgdialect
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var intervalType = reflect.TypeOf((*Interval)(nil)).Elem()
+
+// Interval is a time.Duration that is stored in PostgreSQL as interval data type.
+//
+// Months and years are converted using 30 days per month and 365.25 days per year,
+// which matches how PostgreSQL extracts epoch from an interval.
+//
+//    Timeout pgdialect.Interval
+type Interval time.Duration
+
+var (
+	_ sql.Scanner   = (*Interval)(nil)
+	_ driver.Valuer = (*Interval)(nil)
+)
+
+func (i Interval) Duration() time.Duration {
+	return time.Duration(i)
+}
+
+func (i Interval) String() string {
+	return string(appendInterval(nil, time.Duration(i)))
+}
+
+func (i Interval) Value() (driver.Value, error) {
+	return i.String(), nil
+}
+
+func (i *Interval) Scan(src interface{}) error {
+	var s string
+	switch src := src.(type) {
+	case nil:
+		*i = 0
+		return nil
+	case []byte:
+		s = string(src)
+	case string:
+		s = src
+	default:
+		return fmt.Errorf("bun: Interval can't scan %T", src)
+	}
+
+	d, err := parseInterval(s)
+	if err != nil {
+		return err
+	}
+	*i = Interval(d)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// appendInterval formats the duration as [-]HH:MM:SS[.ffffff].
+// PostgreSQL accepts hours greater than 24 and normalizes them on output.
+func appendInterval(b []byte, d time.Duration) []byte {
+	if d < 0 {
+		b = append(b, '-')
+		d = -d
+	}
+
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+	d -= minutes * time.Minute
+	seconds := d / time.Second
+	d -= seconds * time.Second
+
+	if hours < 10 {
+		b = append(b, '0')
+	}
+	b = strconv.AppendInt(b, int64(hours), 10)
+	b = append(b, ':')
+	b = appendTwoDigits(b, int64(minutes))
+	b = append(b, ':')
+	b = appendTwoDigits(b, int64(seconds))
+
+	if micros := d / time.Microsecond; micros > 0 {
+		frac := strconv.FormatInt(int64(micros)+1e6, 10)[1:]
+		b = append(b, '.')
+		b = append(b, strings.TrimRight(frac, "0")...)
+	}
+
+	return b
+}
+
+func appendTwoDigits(b []byte, n int64) []byte {
+	if n < 10 {
+		b = append(b, '0')
+	}
+	return strconv.AppendInt(b, n, 10)
+}
+
+// parseInterval parses intervals in the default PostgreSQL output style,
+// for example, "02:03:04", "1 day 02:03:04", or "1 year 2 mons -3 days".
+func parseInterval(s string) (time.Duration, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("bun: can't parse interval %q", s)
+	}
+
+	var d time.Duration
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+
+		if strings.IndexByte(field, ':') >= 0 {
+			clock, err := parseIntervalClock(field)
+			if err != nil {
+				return 0, fmt.Errorf("bun: can't parse interval %q: %w", s, err)
+			}
+			d += clock
+			continue
+		}
+
+		if i+1 >= len(fields) {
+			return 0, fmt.Errorf("bun: can't parse interval %q: missing unit", s)
+		}
+
+		n, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("bun: can't parse interval %q: %w", s, err)
+		}
+
+		i++
+		switch unit := fields[i]; unit {
+		case "year", "years":
+			d += time.Duration(n) * 8766 * time.Hour
+		case "mon", "mons":
+			d += time.Duration(n) * 30 * 24 * time.Hour
+		case "day", "days":
+			d += time.Duration(n) * 24 * time.Hour
+		default:
+			return 0, fmt.Errorf("bun: can't parse interval %q: unknown unit %q", s, unit)
+		}
+	}
+
+	return d, nil
+}
+
+// parseIntervalClock parses [+-]HH:MM:SS[.ffffff].
+func parseIntervalClock(s string) (time.Duration, error) {
+	var neg bool
+	switch {
+	case strings.HasPrefix(s, "-"):
+		neg = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+
+	hours, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	minutes, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	secStr := parts[2]
+	var fracStr string
+	if i := strings.IndexByte(secStr, '.'); i >= 0 {
+		secStr, fracStr = secStr[:i], secStr[i+1:]
+	}
+
+	seconds, err := strconv.ParseInt(secStr, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	d := time.Duration(hours)*time.Hour +
+		time.Duration(minutes)*time.Minute +
+		time.Duration(seconds)*time.Second
+
+	if fracStr != "" {
+		if len(fracStr) > 9 {
+			fracStr = fracStr[:9]
+		}
+		fracStr += strings.Repeat("0", 9-len(fracStr))
+		nanos, err := strconv.ParseInt(fracStr, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		d += time.Duration(nanos)
+	}
+
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
@@ -0,0 +1,71 @@
+package pgdialect
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIntervalParser(t *testing.T) {
+	tests := []struct {
+		s string
+		d time.Duration
+	}{
+		{"00:00:00", 0},
+		{"02:03:04", 2*time.Hour + 3*time.Minute + 4*time.Second},
+		{"-02:03:04", -(2*time.Hour + 3*time.Minute + 4*time.Second)},
+		{"00:00:01.5", 1500 * time.Millisecond},
+		{"00:00:00.000123", 123 * time.Microsecond},
+		{"1 day", 24 * time.Hour},
+		{"2 days", 48 * time.Hour},
+		{"1 day 02:03:04", 26*time.Hour + 3*time.Minute + 4*time.Second},
+		{"-1 days +02:03:04", -22*time.Hour + 3*time.Minute + 4*time.Second},
+		{"1 mon 1 day", 31 * 24 * time.Hour},
+		{"1 year", 8766 * time.Hour},
+	}
+
+	for testi, test := range tests {
+		d, err := parseInterval(test.s)
+		if err != nil {
+			t.Fatalf("#%d: %s", testi, err)
+		}
+		if d != test.d {
+			t.Fatalf("#%d: got %s, wanted %s", testi, d, test.d)
+		}
+	}
+}
+
+func TestIntervalParserError(t *testing.T) {
+	for _, s := range []string{"", "1", "1 week", "02:03", "aa:bb:cc"} {
+		if _, err := parseInterval(s); err == nil {
+			t.Fatalf("parsing %q: expected an error", s)
+		}
+	}
+}
+
+func TestIntervalAppend(t *testing.T) {
+	tests := []struct {
+		d time.Duration
+		s string
+	}{
+		{0, "00:00:00"},
+		{2*time.Hour + 3*time.Minute + 4*time.Second, "02:03:04"},
+		{26*time.Hour + 3*time.Minute + 4*time.Second, "26:03:04"},
+		{-(time.Minute + 500*time.Millisecond), "-00:01:00.5"},
+		{123 * time.Microsecond, "00:00:00.000123"},
+	}
+
+	for testi, test := range tests {
+		got := string(appendInterval(nil, test.d))
+		if got != test.s {
+			t.Fatalf("#%d: got %q, wanted %q", testi, got, test.s)
+		}
+
+		d, err := parseInterval(got)
+		if err != nil {
+			t.Fatalf("#%d: %s", testi, err)
+		}
+		if d != test.d {
+			t.Fatalf("#%d: round-trip got %s, wanted %s", testi, d, test.d)
+		}
+	}
+}
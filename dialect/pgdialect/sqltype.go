@@ -80,6 +80,8 @@ func sqlType(typ reflect.Type) string {
 		return pgTypeCidr
 	case jsonRawMessageType:
 		return pgTypeJSONB
+	case intervalType:
+		return pgTypeInterval
 	}
 
 	sqlType := schema.DiscoverSQLType(typ)
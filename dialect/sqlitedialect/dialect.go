@@ -38,7 +38,14 @@ func New() *Dialect {
 		feature.DeleteTableAlias |
 		feature.InsertOnConflict |
 		feature.TableNotExists |
-		feature.SelectExists
+		feature.SelectExists |
+		feature.RenameColumn |
+		feature.AutoIncrementPK |
+		feature.AggFilter |
+		feature.QuotedCollation |
+		feature.RowValues |
+		feature.BoolType |
+		feature.CreateTableAs
 	return d
 }
 
@@ -70,6 +77,32 @@ func (d *Dialect) IdentQuote() byte {
 	return '"'
 }
 
+// IndexesQuery implements schema.IndexInspector.
+func (d *Dialect) IndexesQuery() string {
+	return `SELECT il.name, il."unique", ii.name
+FROM pragma_index_list(?) AS il, pragma_index_info(il.name) AS ii
+ORDER BY il.name, ii.seqno`
+}
+
+// AppendNoLimit implements schema.NoLimitAppender.
+func (d *Dialect) AppendNoLimit(b []byte) []byte {
+	return append(b, " LIMIT -1"...)
+}
+
+// AppendJSONSet implements schema.JSONSetAppender.
+func (d *Dialect) AppendJSONSet(
+	fmter schema.Formatter, b []byte, column string, path []string, value string,
+) []byte {
+	b = append(b, "json_set("...)
+	b = fmter.AppendIdent(b, column)
+	b = append(b, ", "...)
+	b = d.AppendString(b, string(dialect.AppendJSONPath(nil, path)))
+	b = append(b, ", json("...)
+	b = d.AppendString(b, value)
+	b = append(b, "))"...)
+	return b
+}
+
 func (d *Dialect) AppendBytes(b []byte, bs []byte) []byte {
 	if bs == nil {
 		return dialect.AppendNull(b)
@@ -14,6 +14,8 @@ import (
 	"strconv"
 	"sync/atomic"
 	"time"
+
+	"github.com/uptrace/bun"
 )
 
 func init() {
@@ -277,6 +279,14 @@ func (cn *Conn) query(
 	if err != nil {
 		return nil, err
 	}
+
+	if n, ok := bun.FetchSizeFromContext(ctx); ok && n > 0 {
+		if err := writePortalQuery(ctx, cn, query, n); err != nil {
+			return nil, err
+		}
+		return readPortalQueryData(ctx, cn, n)
+	}
+
 	if err := writeQuery(ctx, cn, query); err != nil {
 		return nil, err
 	}
@@ -340,16 +350,27 @@ func (cn *Conn) checkBadConn(err error) error {
 //------------------------------------------------------------------------------
 
 type rows struct {
+	// ctx is the context of the query, which is also used to read the rows
+	// and to fetch them from the portal.
+	ctx      context.Context
 	cn       *Conn
 	rowDesc  *rowDescription
 	reusable bool
 	closed   bool
+
+	// fetchSize is the number of rows fetched at a time from the unnamed portal.
+	fetchSize int
+	// synced is set once SYNC is sent and the portal can't be fetched from.
+	synced bool
+	// closing stops fetching from the portal when the rows are closed early.
+	closing bool
 }
 
 var _ driver.Rows = (*rows)(nil)
 
-func newRows(cn *Conn, rowDesc *rowDescription, reusable bool) *rows {
+func newRows(ctx context.Context, cn *Conn, rowDesc *rowDescription, reusable bool) *rows {
 	return &rows{
+		ctx:      ctx,
 		cn:       cn,
 		rowDesc:  rowDesc,
 		reusable: reusable,
@@ -369,12 +390,19 @@ func (r *rows) Close() error {
 	}
 	defer r.close()
 
+	r.closing = true
 	for {
 		switch err := r.Next(nil); err {
-		case nil, io.EOF:
+		case nil:
+			// Discard the rest of the rows.
+		case io.EOF:
 			return nil
-		default: // unexpected error
-			_ = r.cn.Close()
+		default:
+			// The connection can be reused if the server finished the query
+			// with READY FOR QUERY, for example, after an error mid-fetch.
+			if !r.closed {
+				_ = r.cn.Close()
+			}
 			return err
 		}
 	}
@@ -409,7 +437,7 @@ func (r *rows) Next(dest []driver.Value) error {
 }
 
 func (r *rows) next(dest []driver.Value) (eof bool, _ error) {
-	rd := r.cn.reader(context.TODO(), -1)
+	rd := r.cn.reader(r.ctx, -1)
 	var firstErr error
 	for {
 		c, msgLen, err := readMessageType(rd)
@@ -420,10 +448,30 @@ func (r *rows) next(dest []driver.Value) (eof bool, _ error) {
 		switch c {
 		case dataRowMsg:
 			return false, r.readDataRow(rd, dest)
+		case portalSuspendedMsg: // response to EXECUTE message with the max rows.
+			if err := rd.Discard(msgLen); err != nil {
+				return false, err
+			}
+			if r.closing {
+				if err := r.closePortal(); err != nil {
+					return false, err
+				}
+				continue
+			}
+			if err := writeFetch(r.ctx, r.cn, r.fetchSize); err != nil {
+				return false, err
+			}
+		case closeCompleteMsg: // response to CLOSE message sent by closePortal.
+			if err := rd.Discard(msgLen); err != nil {
+				return false, err
+			}
 		case commandCompleteMsg:
 			if err := rd.Discard(msgLen); err != nil {
 				return false, err
 			}
+			if err := r.sync(); err != nil {
+				return false, err
+			}
 		case readyForQueryMsg:
 			r.close()
 
@@ -447,19 +495,42 @@ func (r *rows) next(dest []driver.Value) (eof bool, _ error) {
 			if firstErr == nil {
 				firstErr = e
 			}
+			if err := r.sync(); err != nil {
+				return false, err
+			}
 		default:
 			return false, fmt.Errorf("pgdriver: Next: unexpected message %q", c)
 		}
 	}
 }
 
+// sync closes the portal of the rows fetched with the fetch size.
+// The server replies with READY FOR QUERY.
+func (r *rows) sync() error {
+	if r.fetchSize == 0 || r.synced {
+		return nil
+	}
+	r.synced = true
+	return writeSync(r.ctx, r.cn)
+}
+
+// closePortal closes the portal of the rows closed before all rows were fetched.
+// Sync alone keeps the portal open until the end of the transaction.
+func (r *rows) closePortal() error {
+	if r.synced {
+		return nil
+	}
+	r.synced = true
+	return writeClosePortal(r.ctx, r.cn)
+}
+
 func (r *rows) readDataRow(rd *reader, dest []driver.Value) error {
 	numCol, err := readInt16(rd)
 	if err != nil {
 		return err
 	}
 
-	if len(dest) != int(numCol) {
+	if dest != nil && len(dest) != int(numCol) {
 		return fmt.Errorf("pgdriver: query returned %d columns, but Scan dest has %d items",
 			numCol, len(dest))
 	}
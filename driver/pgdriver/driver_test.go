@@ -11,6 +11,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/driver/pgdriver"
 )
 
@@ -252,6 +253,115 @@ func TestStatementTimeout(t *testing.T) {
 	require.True(t, pgerr.StatementTimeout())
 }
 
+func TestFetchSize(t *testing.T) {
+	ctx := bun.WithFetchSize(context.Background(), 3)
+
+	db := sqlDB()
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	rows, err := db.QueryContext(ctx, "SELECT generate_series(1, 10)")
+	require.NoError(t, err)
+
+	var nums []int
+	for rows.Next() {
+		var num int
+		require.NoError(t, rows.Scan(&num))
+		nums = append(nums, num)
+	}
+	require.NoError(t, rows.Err())
+	require.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, nums)
+
+	// Closing the rows early closes the portal and keeps the connection usable.
+	rows, err = db.QueryContext(ctx, "SELECT generate_series(1, 10)")
+	require.NoError(t, err)
+	require.True(t, rows.Next())
+	require.NoError(t, rows.Close())
+
+	var num int
+	err = db.QueryRowContext(ctx, "SELECT 1/0").Scan(&num)
+	require.Error(t, err)
+
+	err = db.QueryRowContext(ctx, "SELECT 42").Scan(&num)
+	require.NoError(t, err)
+	require.Equal(t, 42, num)
+}
+
+func TestFetchSizeCloseEarly(t *testing.T) {
+	ctx := bun.WithFetchSize(context.Background(), 3)
+
+	db := sqlDB()
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, "SELECT generate_series(1, 10)")
+	require.NoError(t, err)
+
+	// Stop in the middle of the second batch.
+	for i := 0; i < 4; i++ {
+		require.True(t, rows.Next())
+	}
+	require.NoError(t, rows.Close())
+
+	// The portal is closed, so the transaction can run other queries.
+	var num int
+	err = tx.QueryRowContext(ctx, "SELECT 42").Scan(&num)
+	require.NoError(t, err)
+	require.Equal(t, 42, num)
+
+	require.NoError(t, tx.Commit())
+}
+
+func TestFetchSizeError(t *testing.T) {
+	ctx := bun.WithFetchSize(context.Background(), 2)
+
+	db := sqlDB()
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	var pid int
+	err := db.QueryRowContext(ctx, "SELECT pg_backend_pid()").Scan(&pid)
+	require.NoError(t, err)
+
+	// The error happens while fetching the third batch.
+	rows, err := db.QueryContext(ctx, "SELECT 10 / (5 - n) FROM generate_series(1, 10) AS n")
+	require.NoError(t, err)
+
+	var nums []int
+	for rows.Next() {
+		var num int
+		require.NoError(t, rows.Scan(&num))
+		nums = append(nums, num)
+	}
+	require.Equal(t, []int{2, 3, 5, 10}, nums)
+
+	err = rows.Err()
+	require.Error(t, err)
+	pgerr, ok := err.(pgdriver.Error)
+	require.True(t, ok)
+	require.Equal(t, "22012", pgerr.Field('C'))
+
+	// Closing the rows early still reports the error in the current batch,
+	// but keeps the connection.
+	ctx = bun.WithFetchSize(context.Background(), 10)
+	rows, err = db.QueryContext(ctx, "SELECT 10 / (5 - n) FROM generate_series(1, 10) AS n")
+	require.NoError(t, err)
+	require.True(t, rows.Next())
+	require.Error(t, rows.Close())
+
+	var samePID int
+	err = db.QueryRowContext(ctx, "SELECT pg_backend_pid()").Scan(&samePID)
+	require.NoError(t, err)
+	require.Equal(t, pid, samePID)
+}
+
 func sqlDB() *sql.DB {
 	db, err := sql.Open("pg", dsn())
 	if err != nil {
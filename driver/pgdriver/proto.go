@@ -61,7 +61,8 @@ const (
 	bindMsg         = 'B'
 	bindCompleteMsg = '2'
 
-	executeMsg = 'E'
+	executeMsg         = 'E'
+	portalSuspendedMsg = 's'
 
 	syncMsg  = 'S'
 	flushMsg = 'H'
@@ -544,7 +545,7 @@ func readQueryData(ctx context.Context, cn *Conn) (*rows, error) {
 			if err != nil {
 				return nil, err
 			}
-			return newRows(cn, rowDesc, true), nil
+			return newRows(ctx, cn, rowDesc, true), nil
 		case commandCompleteMsg:
 			if err := rd.Discard(msgLen); err != nil {
 				return nil, err
@@ -895,7 +896,7 @@ func readExtQueryData(ctx context.Context, cn *Conn, rowDesc *rowDescription) (*
 			if err := rd.Discard(msgLen); err != nil {
 				return nil, err
 			}
-			return newRows(cn, rowDesc, false), nil
+			return newRows(ctx, cn, rowDesc, false), nil
 		case commandCompleteMsg: // response to EXECUTE message.
 			if err := rd.Discard(msgLen); err != nil {
 				return nil, err
@@ -930,6 +931,158 @@ func readExtQueryData(ctx context.Context, cn *Conn, rowDesc *rowDescription) (*
 	}
 }
 
+// writePortalQuery creates the unnamed portal for the query and fetches
+// the first maxRows rows. The portal is kept open until writeSync.
+func writePortalQuery(ctx context.Context, cn *Conn, query string, maxRows int) error {
+	wb := getWriteBuffer()
+	defer putWriteBuffer(wb)
+
+	wb.StartMessage(parseMsg)
+	wb.WriteString("")
+	wb.WriteString(query)
+	wb.WriteInt16(0)
+	wb.FinishMessage()
+
+	wb.StartMessage(bindMsg)
+	wb.WriteString("")
+	wb.WriteString("")
+	wb.WriteInt16(0)
+	wb.WriteInt16(0)
+	wb.WriteInt16(0)
+	wb.FinishMessage()
+
+	wb.StartMessage(describeMsg)
+	wb.WriteByte('P') //nolint
+	wb.WriteString("")
+	wb.FinishMessage()
+
+	writePortalExecute(wb, maxRows)
+
+	return cn.write(ctx, wb)
+}
+
+// writeFetch fetches the next maxRows rows from the unnamed portal.
+func writeFetch(ctx context.Context, cn *Conn, maxRows int) error {
+	wb := getWriteBuffer()
+	defer putWriteBuffer(wb)
+
+	writePortalExecute(wb, maxRows)
+
+	return cn.write(ctx, wb)
+}
+
+func writePortalExecute(wb *writeBuffer, maxRows int) {
+	wb.StartMessage(executeMsg)
+	wb.WriteString("")
+	wb.WriteInt32(int32(maxRows))
+	wb.FinishMessage()
+
+	wb.StartMessage(flushMsg)
+	wb.FinishMessage()
+}
+
+func writeSync(ctx context.Context, cn *Conn) error {
+	wb := getWriteBuffer()
+	defer putWriteBuffer(wb)
+
+	wb.StartMessage(syncMsg)
+	wb.FinishMessage()
+
+	return cn.write(ctx, wb)
+}
+
+// writeClosePortal closes the unnamed portal and ends the query with SYNC.
+func writeClosePortal(ctx context.Context, cn *Conn) error {
+	wb := getWriteBuffer()
+	defer putWriteBuffer(wb)
+
+	wb.StartMessage(closeMsg)
+	wb.WriteByte('P') //nolint
+	wb.WriteString("")
+	wb.FinishMessage()
+
+	wb.StartMessage(syncMsg)
+	wb.FinishMessage()
+
+	return cn.write(ctx, wb)
+}
+
+func readPortalQueryData(ctx context.Context, cn *Conn, fetchSize int) (*rows, error) {
+	rd := cn.reader(ctx, -1)
+	var synced bool
+	var firstErr error
+	for {
+		c, msgLen, err := readMessageType(rd)
+		if err != nil {
+			return nil, err
+		}
+
+		switch c {
+		case parseCompleteMsg, bindCompleteMsg, noDataMsg:
+			if err := rd.Discard(msgLen); err != nil {
+				return nil, err
+			}
+		case rowDescriptionMsg: // response to DESCRIBE message.
+			rowDesc, err := readRowDescription(rd)
+			if err != nil {
+				return nil, err
+			}
+			rows := newRows(ctx, cn, rowDesc, true)
+			rows.fetchSize = fetchSize
+			return rows, nil
+		case commandCompleteMsg: // response to EXECUTE message.
+			if err := rd.Discard(msgLen); err != nil {
+				return nil, err
+			}
+			if !synced {
+				if err := writeSync(ctx, cn); err != nil {
+					return nil, err
+				}
+				synced = true
+			}
+		case readyForQueryMsg: // response to SYNC message.
+			if err := rd.Discard(msgLen); err != nil {
+				return nil, err
+			}
+			if firstErr != nil {
+				return nil, firstErr
+			}
+			return &rows{closed: true}, nil
+		case errorResponseMsg:
+			e, err := readError(rd)
+			if err != nil {
+				return nil, err
+			}
+			if firstErr == nil {
+				firstErr = e
+			}
+			// The server skips messages until SYNC after an error.
+			if !synced {
+				if err := writeSync(ctx, cn); err != nil {
+					return nil, err
+				}
+				synced = true
+			}
+		case emptyQueryResponseMsg:
+			if firstErr == nil {
+				firstErr = errEmptyQuery
+			}
+			if !synced {
+				if err := writeSync(ctx, cn); err != nil {
+					return nil, err
+				}
+				synced = true
+			}
+		case noticeResponseMsg, parameterStatusMsg:
+			if err := rd.Discard(msgLen); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("pgdriver: readPortalQueryData: unexpected message %q", c)
+		}
+	}
+}
+
 func writeCloseStmt(ctx context.Context, cn *Conn, name string) error {
 	wb := getWriteBuffer()
 	defer putWriteBuffer(wb)
@@ -7,6 +7,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/uptrace/bun/internal"
 	"github.com/uptrace/bun/schema"
 )
 
@@ -59,7 +60,7 @@ func (db *DB) beforeQuery(
 ) (context.Context, *QueryEvent) {
 	atomic.AddUint32(&db.stats.Queries, 1)
 
-	if len(db.queryHooks) == 0 {
+	if len(db.queryHooks) == 0 && !hasSlowLog(ctx) {
 		return ctx, nil
 	}
 
@@ -104,6 +105,30 @@ func (db *DB) afterQuery(
 	event.Err = err
 
 	db.afterQueryFromIndex(ctx, event, len(db.queryHooks)-1)
+	logSlowQuery(ctx, event)
+}
+
+type slowLogKey struct{}
+
+// withSlowLog returns a copy of the context that makes afterQuery log queries
+// running longer than the threshold. It is set by SelectQuery.SlowLog.
+func withSlowLog(ctx context.Context, threshold time.Duration) context.Context {
+	return context.WithValue(ctx, slowLogKey{}, threshold)
+}
+
+func hasSlowLog(ctx context.Context) bool {
+	_, ok := ctx.Value(slowLogKey{}).(time.Duration)
+	return ok
+}
+
+func logSlowQuery(ctx context.Context, event *QueryEvent) {
+	threshold, ok := ctx.Value(slowLogKey{}).(time.Duration)
+	if !ok {
+		return
+	}
+	if dur := time.Since(event.StartTime); dur > threshold {
+		internal.Logger.Printf("slow query took %s (threshold %s): %s", dur, threshold, event.Query)
+	}
 }
 
 func (db *DB) afterQueryFromIndex(ctx context.Context, event *QueryEvent, hookIndex int) {
@@ -70,6 +70,79 @@ func benchmarkSelectError(b *testing.B, db *bun.DB) {
 	})
 }
 
+func BenchmarkWhereIn(b *testing.B) {
+	benchEachDB(b, func(b *testing.B, db *bun.DB) {
+		benchmarkWhereIn(b, db, func(q *bun.SelectQuery, column string, ids interface{}) *bun.SelectQuery {
+			return q.Where("? IN (?)", bun.Ident(column), bun.In(ids))
+		})
+	})
+}
+
+// BenchmarkWhereInValuesJoin compares `id IN (VALUES ...)` with the regular
+// IN list of BenchmarkWhereIn.
+func BenchmarkWhereInValuesJoin(b *testing.B) {
+	benchEachDB(b, func(b *testing.B, db *bun.DB) {
+		benchmarkWhereIn(b, db, func(q *bun.SelectQuery, column string, ids interface{}) *bun.SelectQuery {
+			return q.WhereInValuesJoin(column, ids)
+		})
+	})
+}
+
+func benchmarkWhereIn(
+	b *testing.B,
+	db *bun.DB,
+	whereIn func(q *bun.SelectQuery, column string, ids interface{}) *bun.SelectQuery,
+) {
+	ids := make([]int64, 5000)
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			var bs []Bench
+			q := db.NewSelect().Model(&bs)
+			if err := whereIn(q, "id", ids).Scan(ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkQueryCache compares formatting a select query with and without
+// DB.WithQueryCache. The query is recorded instead of being executed.
+func BenchmarkQueryCache(b *testing.B) {
+	benchEachDB(b, func(b *testing.B, db *bun.DB) {
+		for _, cached := range []bool{false, true} {
+			name := "uncached"
+			if cached {
+				name = "cached"
+				db = db.WithQueryCache(100)
+			}
+
+			b.Run(name, func(b *testing.B) {
+				b.ReportAllocs()
+				conn := new(queryRecorder)
+
+				for i := 0; i < b.N; i++ {
+					_, err := db.NewSelect().
+						Conn(conn).
+						Model((*Bench)(nil)).
+						Where("id > ?", i).
+						Where("name = ? OR name = ?", "hello", "world").
+						Where("created_at > ?", time.Time{}).
+						OrderExpr("? DESC", bun.Ident("created_at")).
+						Limit(10).
+						Exec(ctx)
+					if err != errQueryRecorded {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	})
+}
+
 func benchEachDB(b *testing.B, f func(b *testing.B, db *bun.DB)) {
 	for name, newDB := range allDBs {
 		b.Run(name, func(b *testing.B) {
@@ -1,16 +1,20 @@
 package dbtest_test
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math/big"
 	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -24,6 +28,8 @@ import (
 	"github.com/uptrace/bun/driver/pgdriver"
 	"github.com/uptrace/bun/driver/sqliteshim"
 	"github.com/uptrace/bun/extra/bundebug"
+	"github.com/uptrace/bun/internal"
+	"github.com/uptrace/bun/schema"
 
 	_ "github.com/denisenkom/go-mssqldb"
 	_ "github.com/go-sql-driver/mysql"
@@ -246,6 +252,50 @@ func TestDB(t *testing.T) {
 		{testSelectJSONStruct},
 		{testJSONSpecialChars},
 		{testSelectRawMessage},
+		{testMsgpackMaxLen},
+		{testScanTextUnmarshaler},
+		{testAppendQueryArgs},
+		{testScanNullNotNull},
+		{testScanEnum},
+		{testDeferConstraints},
+		{testDeleteReturningSlice},
+		{testRegisterScanner},
+		{testSelectExplain},
+		{testDebugChecks},
+		{testScanEmbedPrefix},
+		{testSelectWithTotalCount},
+		{testUnixTime},
+		{testSelectUseReplica},
+		{testScanColumnError},
+		{testTablePrefix},
+		{testCreateIndexName},
+		{testScanPositional},
+		{testKeysetNullable},
+		{testAddColumnNotNullDefault},
+		{testSelectScanColumn},
+		{testPrettyString},
+		{testScanBitBool},
+		{testSelectFetchSize},
+		{testSelectHavingAlias},
+		{testRenameColumn},
+		{testScanNullablePtr},
+		{testCreateTableIdentity},
+		{testInsertReturningMap},
+		{testSelectSlowLog},
+		{testInsertIgnore},
+		{testWhereInChunked},
+		{testScanMulti},
+		{testUpdateReturningChanged},
+		{testWhereInSubqueryLimit},
+		{testScanChan},
+		{testSoftDeleteBool},
+		{testDropIndex},
+		{testTextMarshalerRoundTrip},
+		{testTextMarshalerDefaultJSON},
+		{testEmptyInFalseLiteral},
+		{testQueryCache},
+		{testSoftDeleteReturning},
+		{testSoftDeleteUnixTime},
 		{testScanNullVar},
 		{testScanSingleRow},
 		{testScanSingleRowByRow},
@@ -275,6 +325,9 @@ func TestDB(t *testing.T) {
 		{testJSONMarshaler},
 		{testNilDriverValue},
 		{testRunInTxAndSavepoint},
+		{testIndexes},
+		{testExpressionIndexes},
+		{testWherePKSorted},
 	}
 
 	testEachDB(t, func(t *testing.T, dbName string, db *bun.DB) {
@@ -583,11 +636,1539 @@ func testSelectRawMessage(t *testing.T, db *bun.DB) {
 	require.NoError(t, err)
 	require.Equal(t, `{"hello":"world"}`, string(model.Raw))
 
+	// The raw bytes are copied as is without re-encoding.
+	raw := `{"b": [1,  2],   "a": "x"}`
+	err = db.NewSelect().
+		ColumnExpr("? AS raw", raw).
+		Scan(ctx, model)
+	require.NoError(t, err)
+	require.Equal(t, []byte(raw), []byte(model.Raw))
+
 	err = db.NewSelect().
 		ColumnExpr("NULL AS raw").
 		Scan(ctx, model)
 	require.NoError(t, err)
-	require.Nil(t, model.Raw)
+	require.Nil(t, model.Raw)
+}
+
+func testMsgpackMaxLen(t *testing.T, db *bun.DB) {
+	type Model struct {
+		Data []int `bun:",msgpack"`
+	}
+
+	schema.SetMsgpackMaxLen(3)
+	defer schema.SetMsgpackMaxLen(0)
+
+	model := new(Model)
+	err := db.NewSelect().
+		ColumnExpr("? AS data", []byte{0x93, 0x01, 0x02, 0x03}).
+		Scan(ctx, model)
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2, 3}, model.Data)
+
+	err = db.NewSelect().
+		ColumnExpr("? AS data", []byte{0x94, 0x01, 0x02, 0x03, 0x04}).
+		Scan(ctx, model)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "exceeds the limit of 3")
+
+	schema.SetMsgpackMaxLen(1 << 20)
+
+	// array32 header claiming 4294967295 elements
+	err = db.NewSelect().
+		ColumnExpr("? AS data", []byte{0xdd, 0xff, 0xff, 0xff, 0xff}).
+		Scan(ctx, model)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "exceeds")
+
+	// The limit can be changed while other goroutines scan rows.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			schema.SetMsgpackMaxLen(3 + i%2)
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		err := db.NewSelect().
+			ColumnExpr("? AS data", []byte{0x93, 0x01, 0x02, 0x03}).
+			Scan(ctx, new(Model))
+		require.NoError(t, err)
+	}
+	<-done
+}
+
+type textPoint struct {
+	X, Y string
+}
+
+func (p *textPoint) UnmarshalText(b []byte) error {
+	i := strings.IndexByte(string(b), ':')
+	if i == -1 {
+		return fmt.Errorf("can't parse point %q", b)
+	}
+	p.X, p.Y = string(b[:i]), string(b[i+1:])
+	return nil
+}
+
+func (p textPoint) MarshalText() ([]byte, error) {
+	return []byte(p.X + ":" + p.Y), nil
+}
+
+// textLevel is an integer with a text form like zapcore.Level.
+type textLevel int8
+
+var textLevelNames = []string{"info", "warn"}
+
+func (l *textLevel) UnmarshalText(b []byte) error {
+	for i, name := range textLevelNames {
+		if name == string(b) {
+			*l = textLevel(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown level %q", b)
+}
+
+func (l textLevel) MarshalText() ([]byte, error) {
+	return []byte(textLevelNames[l]), nil
+}
+
+func testScanTextUnmarshaler(t *testing.T, db *bun.DB) {
+	type Model struct {
+		Point    textPoint  `bun:"type:varchar"`
+		PointPtr *textPoint `bun:"type:varchar"`
+	}
+
+	model := new(Model)
+	err := db.NewSelect().
+		ColumnExpr("? AS point", "1:2").
+		ColumnExpr("? AS point_ptr", "3:4").
+		Scan(ctx, model)
+	require.NoError(t, err)
+	require.Equal(t, textPoint{X: "1", Y: "2"}, model.Point)
+	require.Equal(t, &textPoint{X: "3", Y: "4"}, model.PointPtr)
+
+	model = new(Model)
+	err = db.NewSelect().
+		ColumnExpr("NULL AS point").
+		ColumnExpr("NULL AS point_ptr").
+		Scan(ctx, model)
+	require.NoError(t, err)
+	require.Equal(t, textPoint{}, model.Point)
+	require.Nil(t, model.PointPtr)
+}
+
+func testAppendQueryArgs(t *testing.T, db *bun.DB) {
+	q := db.NewSelect().
+		ColumnExpr("? AS str", "hello").
+		Where("1 = ?", 1)
+
+	b, args, err := q.AppendQueryArgs(db.Formatter(), nil)
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"hello", 1}, args)
+
+	switch db.Dialect().Name() {
+	case dialect.PG:
+		require.Equal(t, "SELECT $1 AS str WHERE (1 = $2)", string(b))
+	case dialect.MSSQL:
+		require.Equal(t, "SELECT @p1 AS str WHERE (1 = @p2)", string(b))
+	default:
+		require.Equal(t, "SELECT ? AS str WHERE (1 = ?)", string(b))
+	}
+
+	var str string
+	err = db.DB.QueryRowContext(ctx, string(b), args...).Scan(&str)
+	require.NoError(t, err)
+	require.Equal(t, "hello", str)
+}
+
+func testScanNullNotNull(t *testing.T, db *bun.DB) {
+	type Model struct {
+		Str        string
+		Num        int64
+		StrNotNull string `bun:",scan_notnull"`
+		NumNotNull int64  `bun:",scan_notnull"`
+		DDLNotNull string `bun:",notnull"`
+	}
+
+	model := &Model{Str: "hello", Num: 42, DDLNotNull: "world"}
+	err := db.NewSelect().
+		ColumnExpr("NULL AS str").
+		ColumnExpr("NULL AS num").
+		ColumnExpr("NULL AS ddl_not_null").
+		Scan(ctx, model)
+	require.NoError(t, err)
+	require.Equal(t, "", model.Str)
+	require.Equal(t, int64(0), model.Num)
+	require.Equal(t, "", model.DDLNotNull)
+
+	err = db.NewSelect().
+		ColumnExpr("NULL AS str_not_null").
+		Scan(ctx, model)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `bun: got NULL for the scan_notnull column "str_not_null"`)
+
+	err = db.NewSelect().
+		ColumnExpr("NULL AS num_not_null").
+		Scan(ctx, model)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `bun: got NULL for the scan_notnull column "num_not_null"`)
+}
+
+type enumStatus string
+
+func testScanEnum(t *testing.T, db *bun.DB) {
+	type Model struct {
+		Status    enumStatus  `bun:",enum"`
+		StatusPtr *enumStatus `bun:",enum"`
+	}
+
+	schema.RegisterEnum((*enumStatus)(nil), "active", "disabled")
+
+	model := new(Model)
+	err := db.NewSelect().
+		ColumnExpr("? AS status", "active").
+		ColumnExpr("? AS status_ptr", "disabled").
+		Scan(ctx, model)
+	require.NoError(t, err)
+	require.Equal(t, enumStatus("active"), model.Status)
+	require.Equal(t, enumStatus("disabled"), *model.StatusPtr)
+
+	err = db.NewSelect().
+		ColumnExpr("? AS status", "unknown").
+		Scan(ctx, model)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `"unknown" is not a valid dbtest_test.enumStatus value`)
+}
+
+func testDeferConstraints(t *testing.T, db *bun.DB) {
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	err = tx.DeferConstraints(ctx)
+	if db.Dialect().Name() != dialect.PG {
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "does not support SET CONSTRAINTS")
+		return
+	}
+	require.NoError(t, err)
+}
+
+func testDeleteReturningSlice(t *testing.T, db *bun.DB) {
+	if !db.Dialect().Features().Has(feature.Returning) {
+		t.Skip()
+	}
+
+	type Model struct {
+		bun.BaseModel `bun:"delete_returning_models"`
+
+		ID  int64 `bun:",pk"`
+		Str string
+	}
+
+	_, err := db.NewDropTable().Model((*Model)(nil)).IfExists().Exec(ctx)
+	require.NoError(t, err)
+
+	_, err = db.NewCreateTable().Model((*Model)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	models := []Model{{ID: 1, Str: "one"}, {ID: 2, Str: "two"}, {ID: 3, Str: "three"}}
+	_, err = db.NewInsert().Model(&models).Exec(ctx)
+	require.NoError(t, err)
+
+	var deleted []Model
+	_, err = db.NewDelete().
+		Model(&deleted).
+		Where("id > ?", 1).
+		ReturningAll().
+		Exec(ctx)
+	require.NoError(t, err)
+	require.ElementsMatch(t, models[1:], deleted)
+
+	count, err := db.NewSelect().Model((*Model)(nil)).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+}
+
+// decimal is a numeric value stored as an unscaled integer and a scale.
+type decimal struct {
+	Unscaled *big.Int
+	Scale    int
+}
+
+func (d decimal) String() string {
+	if d.Scale == 0 {
+		return d.Unscaled.String()
+	}
+
+	s := new(big.Int).Abs(d.Unscaled).String()
+	if len(s) <= d.Scale {
+		s = strings.Repeat("0", d.Scale-len(s)+1) + s
+	}
+	s = s[:len(s)-d.Scale] + "." + s[len(s)-d.Scale:]
+	if d.Unscaled.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+func scanDecimal(dest reflect.Value, src interface{}) error {
+	var s string
+	switch src := src.(type) {
+	case nil:
+		dest.Set(reflect.Zero(dest.Type()))
+		return nil
+	case []byte:
+		s = string(src)
+	case string:
+		s = src
+	default:
+		return fmt.Errorf("can't scan %T into decimal", src)
+	}
+
+	var scale int
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		scale = len(s) - i - 1
+		s = s[:i] + s[i+1:]
+	}
+
+	unscaled, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return fmt.Errorf("can't parse %q as decimal", s)
+	}
+	dest.Set(reflect.ValueOf(decimal{Unscaled: unscaled, Scale: scale}))
+	return nil
+}
+
+func testRegisterScanner(t *testing.T, db *bun.DB) {
+	schema.RegisterScanner((*decimal)(nil), scanDecimal)
+
+	type Model struct {
+		Num    decimal
+		NumPtr *decimal
+	}
+
+	for _, num := range []string{
+		"12345678901234567890.123456789",
+		"0.05",
+		"-1.25",
+		"-0.005",
+	} {
+		model := new(Model)
+		err := db.NewSelect().
+			ColumnExpr("? AS num", num).
+			ColumnExpr("? AS num_ptr", num).
+			Scan(ctx, model)
+		require.NoError(t, err)
+		require.Equal(t, num, model.Num.String())
+		require.Equal(t, num, model.NumPtr.String())
+	}
+}
+
+func testSelectExplain(t *testing.T, db *bun.DB) {
+	q := db.NewSelect().ColumnExpr("1")
+
+	plan, err := q.Explain(ctx)
+	if db.Dialect().Name() == dialect.MSSQL {
+		require.Error(t, err)
+		return
+	}
+	require.NoError(t, err)
+	require.NotEmpty(t, plan)
+}
+
+func testDebugChecks(t *testing.T, db *bun.DB) {
+	db = bun.NewDB(db.DB, db.Dialect(), bun.WithDebugChecks())
+
+	tests := []struct {
+		query string
+		args  []interface{}
+		ok    bool
+	}{
+		{query: "count(*)", ok: true},
+		{query: "coalesce(max(id), 0) + (1)", ok: true},
+		{query: "? || ')'", args: []interface{}{"("}, ok: true},
+		{query: `"col(" = 1`, ok: true},
+		{query: "count(*", ok: false},
+		{query: "max(id))", ok: false},
+		{query: ")(", ok: false},
+	}
+
+	for _, test := range tests {
+		_, err := db.NewSelect().
+			ColumnExpr(test.query, test.args...).
+			AppendQuery(db.Formatter(), nil)
+		if test.ok {
+			require.NoError(t, err, test.query)
+		} else {
+			require.Error(t, err, test.query)
+			require.Contains(t, err.Error(), "unbalanced parentheses")
+		}
+
+		_, err = db.NewSelect().
+			TableExpr("t").
+			Where(test.query, test.args...).
+			AppendQuery(db.Formatter(), nil)
+		require.Equal(t, test.ok, err == nil, test.query)
+	}
+}
+
+func testScanEmbedPrefix(t *testing.T, db *bun.DB) {
+	type User struct {
+		ID   int64
+		Name string
+	}
+
+	type Org struct {
+		ID   int64
+		Name string
+	}
+
+	type UserWithOrg struct {
+		User `bun:"user,embed"`
+		Org  `bun:"org,embed"`
+	}
+
+	model := new(UserWithOrg)
+	err := db.NewSelect().
+		TableExpr("(SELECT 1 AS id, 'alice' AS name) AS u").
+		Join("JOIN (SELECT 2 AS id, 'acme' AS name) AS o ON 1 = 1").
+		ColumnExpr("u.id AS user_id, u.name AS user_name").
+		ColumnExpr("o.id AS org_id, o.name AS org_name").
+		Scan(ctx, model)
+	require.NoError(t, err)
+	require.Equal(t, User{ID: 1, Name: "alice"}, model.User)
+	require.Equal(t, Org{ID: 2, Name: "acme"}, model.Org)
+
+	// The tag name of named fields does not add a prefix.
+	type NamedEmbed struct {
+		Owner User `bun:"owner,embed"`
+		Org   Org  `bun:"embed:org_"`
+	}
+
+	named := new(NamedEmbed)
+	err = db.NewSelect().
+		ColumnExpr("1 AS id, 'alice' AS name, 2 AS org_id, 'acme' AS org_name").
+		Scan(ctx, named)
+	require.NoError(t, err)
+	require.Equal(t, User{ID: 1, Name: "alice"}, named.Owner)
+	require.Equal(t, Org{ID: 2, Name: "acme"}, named.Org)
+}
+
+func testSelectWithTotalCount(t *testing.T, db *bun.DB) {
+	if db.Dialect().Name() == dialect.MySQL {
+		var version string
+		err := db.NewSelect().ColumnExpr("version()").Scan(ctx, &version)
+		require.NoError(t, err)
+		if strings.HasPrefix(version, "5.") {
+			t.Skip("window functions require MySQL 8")
+		}
+	}
+
+	type Model struct {
+		ID  int64 `bun:",pk,autoincrement"`
+		Str string
+	}
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	models := []Model{{Str: "a"}, {Str: "b"}, {Str: "c"}}
+	_, err = db.NewInsert().Model(&models).Exec(ctx)
+	require.NoError(t, err)
+
+	models = nil
+	q := db.NewSelect().Model(&models).Order("id").Limit(2).WithTotalCount()
+	require.Contains(t, q.String(), "count(*) OVER () AS ")
+
+	err = q.Scan(ctx)
+	require.NoError(t, err)
+	require.Len(t, models, 2)
+	require.Equal(t, 3, q.TotalCount())
+
+	model := new(Model)
+	q = db.NewSelect().Model(model).Order("id").Limit(1).WithTotalCount()
+	err = q.Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "a", model.Str)
+	require.Equal(t, 3, q.TotalCount())
+}
+
+func testUnixTime(t *testing.T, db *bun.DB) {
+	type Model struct {
+		ID        int64      `bun:",pk,autoincrement"`
+		Seconds   time.Time  `bun:",unixtime"`
+		Millis    time.Time  `bun:",unixmilli"`
+		SecondPtr *time.Time `bun:",unixtime"`
+	}
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	tm := time.Unix(1600000000, 123456789)
+	_, err = db.NewInsert().Model(&Model{Seconds: tm, Millis: tm}).Exec(ctx)
+	require.NoError(t, err)
+
+	var seconds, millis int64
+	err = db.NewSelect().
+		Model((*Model)(nil)).
+		Column("seconds", "millis").
+		Scan(ctx, &seconds, &millis)
+	require.NoError(t, err)
+	require.Equal(t, int64(1600000000), seconds)
+	require.Equal(t, int64(1600000000123), millis)
+
+	model := new(Model)
+	err = db.NewSelect().Model(model).Scan(ctx)
+	require.NoError(t, err)
+	require.True(t, model.Seconds.Equal(time.Unix(1600000000, 0)))
+	require.True(t, model.Millis.Equal(time.Unix(1600000000, 123000000)))
+	require.Nil(t, model.SecondPtr)
+
+	model = new(Model)
+	err = db.NewSelect().
+		ColumnExpr("NULL AS seconds").
+		ColumnExpr("0 AS millis").
+		Scan(ctx, model)
+	require.NoError(t, err)
+	require.True(t, model.Seconds.IsZero())
+	require.True(t, model.Millis.IsZero())
+
+	// Nanoseconds overflow int64 after the year 2262.
+	tm = time.Date(3000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	_, err = db.NewInsert().Model(&Model{Seconds: tm, Millis: tm}).Exec(ctx)
+	require.NoError(t, err)
+
+	model = new(Model)
+	err = db.NewSelect().Model(model).Order("id DESC").Limit(1).Scan(ctx)
+	require.NoError(t, err)
+	require.True(t, model.Seconds.Equal(tm))
+	require.True(t, model.Millis.Equal(tm))
+}
+
+type countingConn struct {
+	bun.IConn
+	queries int
+}
+
+func (c *countingConn) QueryContext(
+	ctx context.Context, query string, args ...interface{},
+) (*sql.Rows, error) {
+	c.queries++
+	return c.IConn.QueryContext(ctx, query, args...)
+}
+
+func (c *countingConn) QueryRowContext(
+	ctx context.Context, query string, args ...interface{},
+) *sql.Row {
+	c.queries++
+	return c.IConn.QueryRowContext(ctx, query, args...)
+}
+
+func testSelectUseReplica(t *testing.T, db *bun.DB) {
+	db = bun.NewDB(db.DB, db.Dialect())
+
+	replica1 := &countingConn{IConn: db.DB}
+	replica2 := &countingConn{IConn: db.DB}
+	db.AddReplica(replica1)
+	db.AddReplica(replica2)
+
+	var num int
+	err := db.NewSelect().ColumnExpr("1").Scan(ctx, &num)
+	require.NoError(t, err)
+	require.Equal(t, 0, replica1.queries+replica2.queries)
+
+	for i := 0; i < 4; i++ {
+		err := db.NewSelect().ColumnExpr("1").UseReplica().Scan(ctx, &num)
+		require.NoError(t, err)
+		require.Equal(t, 1, num)
+	}
+	require.Equal(t, 2, replica1.queries)
+	require.Equal(t, 2, replica2.queries)
+
+	err = db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		return tx.NewSelect().ColumnExpr("1").UseReplica().Scan(ctx, &num)
+	})
+	require.NoError(t, err)
+	require.Equal(t, 4, replica1.queries+replica2.queries)
+
+	// Replicas added to a copy of the DB are not used by the original.
+	clone := db.WithNamedArg("foo", "bar")
+	replica3 := &countingConn{IConn: db.DB}
+	clone.AddReplica(replica3)
+
+	for i := 0; i < 3; i++ {
+		err := db.NewSelect().ColumnExpr("1").UseReplica().Scan(ctx, &num)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 0, replica3.queries)
+	require.Equal(t, 7, replica1.queries+replica2.queries)
+}
+
+func testScanColumnError(t *testing.T, db *bun.DB) {
+	type Model struct {
+		ID     int64
+		Amount float64
+	}
+
+	model := new(Model)
+	err := db.NewSelect().
+		ColumnExpr("1 AS id").
+		ColumnExpr("'abc' AS amount").
+		Scan(ctx, model)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `bun: scan column "amount" into float64: `)
+}
+
+func testTablePrefix(t *testing.T, db *bun.DB) {
+	type Model struct {
+		ID  int64 `bun:",pk,autoincrement"`
+		Str string
+	}
+
+	db = bun.NewDB(db.DB, db.Dialect()).WithTablePrefix("prefixed_")
+
+	var queries []string
+	db.AddQueryHook(&queryHook{
+		beforeQuery: func(ctx context.Context, event *bun.QueryEvent) context.Context {
+			queries = append(queries, event.Query)
+			return ctx
+		},
+	})
+
+	_, err := db.NewDropTable().Model((*Model)(nil)).IfExists().Exec(ctx)
+	require.NoError(t, err)
+
+	_, err = db.NewCreateTable().Model((*Model)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	model := &Model{Str: "hello"}
+	_, err = db.NewInsert().Model(model).Exec(ctx)
+	require.NoError(t, err)
+
+	model.Str = "world"
+	_, err = db.NewUpdate().Model(model).WherePK().Exec(ctx)
+	require.NoError(t, err)
+
+	err = db.NewSelect().Model(model).WherePK().Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "world", model.Str)
+
+	_, err = db.NewDelete().Model(model).WherePK().Exec(ctx)
+	require.NoError(t, err)
+
+	_, err = db.NewDropTable().Model((*Model)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	require.NotEmpty(t, queries)
+	for _, query := range queries {
+		require.Contains(t, query, "prefixed_models")
+	}
+}
+
+func testCreateIndexName(t *testing.T, db *bun.DB) {
+	for _, q := range []*bun.CreateIndexQuery{
+		db.NewCreateIndex().Table("users").Column("name", "email"),
+		db.NewCreateIndex().Table("users").Column(strings.Repeat("long_column_", 10)),
+		db.NewCreateIndex().Table("users").Index("users_name_idx").Column("name"),
+	} {
+		name := q.IndexName()
+		require.NotEmpty(t, name)
+
+		query, err := q.AppendQuery(db.Formatter(), nil)
+		require.NoError(t, err)
+
+		ident := db.Formatter().AppendIdent(nil, name)
+		require.Contains(t, string(query), "INDEX "+string(ident)+" ON ")
+	}
+
+	q := db.NewCreateIndex().Table("users").Column("name")
+	require.Equal(t, q.IndexName(), q.IndexName())
+	require.Equal(t, "idx_users_name", q.IndexName())
+}
+
+func testScanPositional(t *testing.T, db *bun.DB) {
+	var id int64
+	var name string
+	var count int
+	err := db.NewSelect().
+		ColumnExpr("42").
+		ColumnExpr("'hello'").
+		ColumnExpr("3").
+		Scan(ctx, &id, &name, &count)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), id)
+	require.Equal(t, "hello", name)
+	require.Equal(t, 3, count)
+
+	err = db.NewSelect().
+		ColumnExpr("42").
+		ColumnExpr("'hello'").
+		Scan(ctx, &id, &name, &count)
+	require.Error(t, err)
+	require.Equal(t, "bun: got 2 columns, but Scan has 3 destinations", err.Error())
+}
+
+func testKeysetNullable(t *testing.T, db *bun.DB) {
+	if db.Dialect().Name() == dialect.MSSQL {
+		t.Skip("mssql does not support row values")
+	}
+
+	type Model struct {
+		ID    int64 `bun:",pk"`
+		Score *int64
+	}
+
+	_, err := db.NewDropTable().Model((*Model)(nil)).IfExists().Exec(ctx)
+	require.NoError(t, err)
+
+	_, err = db.NewCreateTable().Model((*Model)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	score := func(n int64) *int64 { return &n }
+	models := []Model{
+		{ID: 1, Score: score(10)},
+		{ID: 2, Score: nil},
+		{ID: 3, Score: score(5)},
+		{ID: 4, Score: nil},
+		{ID: 5, Score: score(10)},
+	}
+	_, err = db.NewInsert().Model(&models).Exec(ctx)
+	require.NoError(t, err)
+
+	var ids []int64
+	var cursorScore, cursorID int64 = -1, 0
+	for {
+		var page []Model
+		err := db.NewSelect().
+			Model(&page).
+			Where("(COALESCE(score, -1), id) > (?, ?)", cursorScore, cursorID).
+			OrderExpr("COALESCE(score, -1), id").
+			Limit(2).
+			Scan(ctx)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+
+		for _, m := range page {
+			ids = append(ids, m.ID)
+		}
+		last := page[len(page)-1]
+		cursorScore, cursorID = -1, last.ID
+		if last.Score != nil {
+			cursorScore = *last.Score
+		}
+	}
+	require.Equal(t, []int64{2, 4, 3, 1, 5}, ids)
+
+	err = db.NewSelect().
+		Model(new(Model)).
+		WhereTupleGreater([]string{"score", "id"}, []interface{}{(*int64)(nil), 2}).
+		Scan(ctx)
+	require.Error(t, err)
+}
+
+func testAddColumnNotNullDefault(t *testing.T, db *bun.DB) {
+	type Model struct {
+		ID int64 `bun:",pk"`
+	}
+
+	type ModelWithStatus struct {
+		bun.BaseModel `bun:"table:models"`
+
+		ID     int64 `bun:",pk"`
+		Status string
+	}
+
+	_, err := db.NewDropTable().Model((*Model)(nil)).IfExists().Exec(ctx)
+	require.NoError(t, err)
+
+	_, err = db.NewCreateTable().Model((*Model)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	_, err = db.NewInsert().Model(&Model{ID: 1}).Exec(ctx)
+	require.NoError(t, err)
+
+	_, err = db.NewAddColumn().
+		Model((*Model)(nil)).
+		AddColumn("status", "varchar(20)").
+		NotNull().
+		Default("?", "active").
+		Exec(ctx)
+	require.NoError(t, err)
+
+	model := new(ModelWithStatus)
+	err = db.NewSelect().Model(model).Where("id = 1").Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "active", model.Status)
+
+	_, err = db.NewInsert().Model(&ModelWithStatus{ID: 2}).Value("status", "NULL").Exec(ctx)
+	require.Error(t, err)
+}
+
+func testSelectScanColumn(t *testing.T, db *bun.DB) {
+	type Model struct {
+		ID   int64 `bun:",pk"`
+		Name string
+	}
+
+	_, err := db.NewDropTable().Model((*Model)(nil)).IfExists().Exec(ctx)
+	require.NoError(t, err)
+
+	_, err = db.NewCreateTable().Model((*Model)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	models := []Model{{ID: 1, Name: "one"}, {ID: 2, Name: "two"}}
+	_, err = db.NewInsert().Model(&models).Exec(ctx)
+	require.NoError(t, err)
+
+	q := db.NewSelect().Model((*Model)(nil)).Column("id", "name").Order("id")
+
+	var names []string
+	err = q.ScanColumn(ctx, "name", &names)
+	require.NoError(t, err)
+	require.Equal(t, []string{"one", "two"}, names)
+
+	var ids []int64
+	err = q.ScanColumn(ctx, 0, &ids)
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 2}, ids)
+
+	err = q.ScanColumn(ctx, "unknown", &names)
+	require.Error(t, err)
+	require.Equal(t, `bun: ScanColumn got unknown column "unknown"`, err.Error())
+}
+
+func testPrettyString(t *testing.T, db *bun.DB) {
+	type Model struct {
+		ID  int64
+		Str string
+	}
+
+	q := db.NewSelect().
+		Model((*Model)(nil)).
+		Where("str = 'FROM x WHERE y'").
+		Where("id IN (SELECT 1 WHERE true)").
+		Group("id").
+		Order("id")
+
+	pretty := q.PrettyString()
+	require.Equal(t, q.String(), strings.ReplaceAll(pretty, "\n", " "))
+
+	lines := strings.Split(pretty, "\n")
+	require.Len(t, lines, 5)
+	for i, prefix := range []string{"SELECT ", "FROM ", "WHERE ", "GROUP BY ", "ORDER BY "} {
+		require.True(t, strings.HasPrefix(lines[i], prefix), lines[i])
+	}
+}
+
+func testScanBitBool(t *testing.T, db *bun.DB) {
+	var on, off string
+	switch db.Dialect().Name() {
+	case dialect.PG:
+		on, off = "B'1'::bit(1)", "B'0'::bit(1)"
+	case dialect.MySQL:
+		on, off = "b'1'", "b'0'"
+	case dialect.SQLite:
+		on, off = "X'01'", "X'00'"
+	default:
+		t.Skip()
+	}
+
+	var b1, b2 bool
+	err := db.NewSelect().ColumnExpr(on).ColumnExpr(off).Scan(ctx, &b1, &b2)
+	require.NoError(t, err)
+	require.True(t, b1)
+	require.False(t, b2)
+}
+
+type fetchSizeConn struct {
+	bun.IConn
+	fetchSizes []int
+}
+
+func (c *fetchSizeConn) QueryContext(
+	ctx context.Context, query string, args ...interface{},
+) (*sql.Rows, error) {
+	n, _ := bun.FetchSizeFromContext(ctx)
+	c.fetchSizes = append(c.fetchSizes, n)
+	return c.IConn.QueryContext(ctx, query, args...)
+}
+
+func testSelectFetchSize(t *testing.T, db *bun.DB) {
+	conn := &fetchSizeConn{IConn: db.DB}
+
+	var num int
+	err := db.NewSelect().Conn(conn).ColumnExpr("1").FetchSize(100).Scan(ctx, &num)
+	require.NoError(t, err)
+	require.Equal(t, 1, num)
+
+	rows, err := db.NewSelect().Conn(conn).ColumnExpr("1").FetchSize(10).Rows(ctx)
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+
+	err = db.NewSelect().Conn(conn).ColumnExpr("1").Scan(ctx, &num)
+	require.NoError(t, err)
+
+	require.Equal(t, []int{100, 10, 0}, conn.fetchSizes)
+}
+
+func testSelectHavingAlias(t *testing.T, db *bun.DB) {
+	type Model struct {
+		ID       int64 `bun:",pk"`
+		Category string
+	}
+
+	_, err := db.NewDropTable().Model((*Model)(nil)).IfExists().Exec(ctx)
+	require.NoError(t, err)
+
+	_, err = db.NewCreateTable().Model((*Model)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	models := []Model{
+		{ID: 1, Category: "a"},
+		{ID: 2, Category: "a"},
+		{ID: 3, Category: "b"},
+	}
+	_, err = db.NewInsert().Model(&models).Exec(ctx)
+	require.NoError(t, err)
+
+	having := "total > ?"
+	switch db.Dialect().Name() {
+	case dialect.PG, dialect.MSSQL:
+		having = "count(*) > ?"
+	}
+
+	var categories []string
+	err = db.NewSelect().
+		Model((*Model)(nil)).
+		Column("category").
+		ColumnExpr("count(*) AS total").
+		Group("category").
+		Having(having, 1).
+		ScanColumn(ctx, "category", &categories)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, categories)
+}
+
+func testRenameColumn(t *testing.T, db *bun.DB) {
+	type Model struct {
+		ID  int64 `bun:",pk"`
+		Str string
+	}
+
+	type RenamedModel struct {
+		bun.BaseModel `bun:"table:models"`
+
+		ID   int64 `bun:",pk"`
+		Name string
+	}
+
+	_, err := db.NewDropTable().Model((*Model)(nil)).IfExists().Exec(ctx)
+	require.NoError(t, err)
+
+	_, err = db.NewCreateTable().Model((*Model)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	_, err = db.NewInsert().Model(&Model{ID: 1, Str: "hello"}).Exec(ctx)
+	require.NoError(t, err)
+
+	_, err = db.NewAlterColumn().
+		Model((*Model)(nil)).
+		RenameColumn("str", "name").
+		Type("varchar(255)").
+		Exec(ctx)
+	require.NoError(t, err)
+
+	model := new(RenamedModel)
+	err = db.NewSelect().Model(model).Where("id = 1").Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "hello", model.Name)
+}
+
+func testScanNullablePtr(t *testing.T, db *bun.DB) {
+	type Model struct {
+		ID  int64
+		Num *int
+	}
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	num := 42
+	models := []Model{{ID: 1}, {ID: 2, Num: &num}}
+	_, err = db.NewInsert().Model(&models).Exec(ctx)
+	require.NoError(t, err)
+
+	old := 1
+	model := Model{Num: &old}
+	err = db.NewSelect().Model(&model).Where("id = 1").Scan(ctx)
+	require.NoError(t, err)
+	require.Nil(t, model.Num)
+	require.Equal(t, 1, old)
+
+	err = db.NewSelect().Model(&model).Where("id = 2").Scan(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, model.Num)
+	require.Equal(t, 42, *model.Num)
+
+	var ptr *int
+	err = db.NewSelect().ColumnExpr("NULL").Scan(ctx, &ptr)
+	require.NoError(t, err)
+	require.Nil(t, ptr)
+
+	var ptrptr **int
+	err = db.NewSelect().ColumnExpr("42").Scan(ctx, &ptrptr)
+	require.NoError(t, err)
+	require.NotNil(t, ptrptr)
+	require.Equal(t, 42, **ptrptr)
+}
+
+func testCreateTableIdentity(t *testing.T, db *bun.DB) {
+	type Model struct {
+		ID   int64 `bun:",pk,autoincrement,identity:always"`
+		Name string
+	}
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	models := []Model{{Name: "a"}, {Name: "b"}}
+	for i := range models {
+		_, err = db.NewInsert().Model(&models[i]).Exec(ctx)
+		require.NoError(t, err)
+	}
+
+	var ids []int64
+	err = db.NewSelect().Model((*Model)(nil)).Column("id").Order("id").Scan(ctx, &ids)
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 2}, ids)
+	require.Equal(t, int64(2), models[1].ID)
+}
+
+func testInsertReturningMap(t *testing.T, db *bun.DB) {
+	if !db.Dialect().Features().Has(feature.InsertReturning) {
+		t.Skip()
+	}
+
+	type Model struct {
+		ID   int64 `bun:",pk,autoincrement"`
+		Name string
+		Num  int64 `bun:",notnull,default:42"`
+	}
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	values := map[string]interface{}{"name": "hello"}
+	_, err = db.NewInsert().Model(&values).TableExpr("models").ReturningAll().Exec(ctx)
+	require.NoError(t, err)
+	require.Len(t, values, 3)
+	require.EqualValues(t, 1, values["id"])
+	require.Equal(t, "hello", values["name"])
+	require.EqualValues(t, 42, values["num"])
+}
+
+type slowConn struct {
+	bun.IConn
+	delay time.Duration
+}
+
+func (c *slowConn) QueryContext(
+	ctx context.Context, query string, args ...interface{},
+) (*sql.Rows, error) {
+	time.Sleep(c.delay)
+	return c.IConn.QueryContext(ctx, query, args...)
+}
+
+type logRecorder struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (l *logRecorder) Printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, fmt.Sprintf(format, v...))
+}
+
+func testSelectSlowLog(t *testing.T, db *bun.DB) {
+	prevLogger := internal.Logger
+	t.Cleanup(func() {
+		bun.SetLogger(prevLogger)
+	})
+
+	logger := new(logRecorder)
+	bun.SetLogger(logger)
+
+	conn := &slowConn{IConn: db.DB, delay: 20 * time.Millisecond}
+
+	var num int
+	err := db.NewSelect().Conn(conn).ColumnExpr("1").SlowLog(time.Hour).Scan(ctx, &num)
+	require.NoError(t, err)
+	require.Empty(t, logger.entries)
+
+	err = db.NewSelect().Conn(conn).ColumnExpr("1").SlowLog(time.Millisecond).Scan(ctx, &num)
+	require.NoError(t, err)
+	require.Len(t, logger.entries, 1)
+	require.Contains(t, logger.entries[0], "slow query took")
+	require.Contains(t, logger.entries[0], "SELECT 1")
+}
+
+func testInsertIgnore(t *testing.T, db *bun.DB) {
+	if !db.Dialect().Features().Has(feature.InsertOnConflict | feature.InsertIgnore) {
+		t.Skip()
+	}
+
+	type Model struct {
+		ID  int64 `bun:",pk"`
+		Str string
+	}
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	_, err = db.NewInsert().Model(&Model{ID: 1, Str: "first"}).Exec(ctx)
+	require.NoError(t, err)
+
+	_, err = db.NewInsert().Model(&Model{ID: 1, Str: "second"}).Exec(ctx)
+	require.Error(t, err)
+
+	_, err = db.NewInsert().Model(&Model{ID: 1, Str: "second"}).Ignore().Exec(ctx)
+	require.NoError(t, err)
+
+	var models []Model
+	err = db.NewSelect().Model(&models).Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []Model{{ID: 1, Str: "first"}}, models)
+}
+
+func testWhereInChunked(t *testing.T, db *bun.DB) {
+	type Model struct {
+		ID int64 `bun:",pk"`
+	}
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	models := []Model{{ID: 1}, {ID: 1500}, {ID: 2500}, {ID: 3000}}
+	_, err = db.NewInsert().Model(&models).Exec(ctx)
+	require.NoError(t, err)
+
+	ids := make([]int64, 2500)
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+
+	q := db.NewSelect().Model((*Model)(nil)).WhereInChunked("id", ids)
+	require.Equal(t, 3, strings.Count(q.String(), " IN ("))
+
+	n, err := q.Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+}
+
+func testScanMulti(t *testing.T, db *bun.DB) {
+	var num1, num2 int
+	err := db.Raw("SELECT 1").ScanMulti(ctx, &num1, &num2)
+	require.Error(t, err)
+	require.Equal(t, "bun: got 1 result sets, but ScanMulti has 2 destinations", err.Error())
+
+	// Only the MSSQL driver returns multiple result sets without extra options.
+	if db.Dialect().Name() != dialect.MSSQL {
+		t.Skip()
+	}
+
+	var nums []int
+	var str string
+	err = db.Raw("SELECT 1 UNION ALL SELECT 2; SELECT 'hello'").ScanMulti(ctx, &nums, &str)
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2}, nums)
+	require.Equal(t, "hello", str)
+}
+
+func testUpdateReturningChanged(t *testing.T, db *bun.DB) {
+	if !db.Dialect().Features().Has(feature.Returning) {
+		t.Skip()
+	}
+
+	type Model struct {
+		ID   int64 `bun:",pk,autoincrement"`
+		Name string
+		Age  int
+		Bio  string
+	}
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	model := &Model{Name: "John", Age: 30, Bio: "bio"}
+	_, err = db.NewInsert().Model(model).Exec(ctx)
+	require.NoError(t, err)
+
+	q := db.NewUpdate().
+		Model((*Model)(nil)).
+		Set("name = ?", "Jane").
+		Set("age = age + 1").
+		Where("id = ?", model.ID).
+		ReturningChanged()
+	query := q.String()
+	require.True(t, strings.HasSuffix(query, ` RETURNING "name", "age"`), query)
+
+	var name string
+	var age int
+	_, err = q.Exec(ctx, &name, &age)
+	require.NoError(t, err)
+	require.Equal(t, "Jane", name)
+	require.Equal(t, 31, age)
+}
+
+func testWhereInSubqueryLimit(t *testing.T, db *bun.DB) {
+	if db.Dialect().Name() == dialect.MySQL {
+		// MySQL doesn't support LIMIT in IN subqueries.
+		t.Skip()
+	}
+
+	type Model struct {
+		ID int64 `bun:",pk"`
+	}
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	models := make([]Model, 10)
+	for i := range models {
+		models[i].ID = int64(i + 1)
+	}
+	_, err = db.NewInsert().Model(&models).Exec(ctx)
+	require.NoError(t, err)
+
+	subq := db.NewSelect().
+		Model((*Model)(nil)).
+		Column("id").
+		Order("id").
+		Limit(3)
+
+	var ids []int64
+	err = db.NewSelect().
+		Model((*Model)(nil)).
+		Column("id").
+		Where("id IN (?)", subq).
+		Order("id").
+		Scan(ctx, &ids)
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 2, 3}, ids)
+}
+
+func testScanChan(t *testing.T, db *bun.DB) {
+	type Model struct {
+		ID  int64 `bun:",pk"`
+		Str string
+	}
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	models := []Model{{ID: 1, Str: "a"}, {ID: 2, Str: "b"}, {ID: 3, Str: "c"}}
+	_, err = db.NewInsert().Model(&models).Exec(ctx)
+	require.NoError(t, err)
+
+	ch := make(chan Model, len(models))
+	err = db.NewSelect().Model((*Model)(nil)).Order("id").Scan(ctx, ch)
+	require.NoError(t, err)
+
+	var got []Model
+	for model := range ch {
+		got = append(got, model)
+	}
+	require.Equal(t, models, got)
+
+	ids := make(chan *int64, len(models))
+	err = db.NewSelect().Model((*Model)(nil)).Column("id").Order("id").Scan(ctx, ids)
+	require.NoError(t, err)
+
+	var gotIDs []int64
+	for id := range ids {
+		gotIDs = append(gotIDs, *id)
+	}
+	require.Equal(t, []int64{1, 2, 3}, gotIDs)
+
+	ctx, cancel := context.WithCancel(ctx)
+	unbuffered := make(chan Model)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- db.NewSelect().Model((*Model)(nil)).Order("id").Scan(ctx, unbuffered)
+	}()
+
+	model := <-unbuffered
+	require.Equal(t, models[0], model)
+	cancel()
+
+	require.Error(t, <-errc)
+	for range unbuffered {
+	}
+
+	// The channel is closed when the query fails before scanning.
+	failed := make(chan Model)
+	err = db.NewSelect().Model((*Model)(nil)).Relation("Missing").Scan(ctx, failed)
+	require.Error(t, err)
+	_, ok := <-failed
+	require.False(t, ok)
+
+	type Author struct {
+		ID int64 `bun:",pk"`
+	}
+	type Book struct {
+		ID       int64 `bun:",pk"`
+		AuthorID int64
+		Author   *Author `bun:"rel:belongs-to,join:author_id=id"`
+	}
+
+	books := make(chan Book)
+	err = db.NewSelect().Model((*Book)(nil)).Relation("Author").Scan(ctx, books)
+	require.EqualError(t, err, "bun: Relation is not supported when scanning into a channel")
+	_, ok = <-books
+	require.False(t, ok)
+}
+
+func testSoftDeleteBool(t *testing.T, db *bun.DB) {
+	type Model struct {
+		ID      int64        `bun:",pk"`
+		Deleted sql.NullBool `bun:",soft_delete:bool"`
+	}
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	models := []Model{
+		{ID: 1},
+		{ID: 2, Deleted: sql.NullBool{Bool: false, Valid: true}},
+		{ID: 3, Deleted: sql.NullBool{Bool: true, Valid: true}},
+	}
+	_, err = db.NewInsert().Model(&models).Exec(ctx)
+	require.NoError(t, err)
+
+	var ids []int64
+	err = db.NewSelect().Model((*Model)(nil)).Column("id").Order("id").Scan(ctx, &ids)
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 2}, ids)
+
+	model := &Model{ID: 1}
+	_, err = db.NewDelete().Model(model).WherePK().Exec(ctx)
+	require.NoError(t, err)
+	require.Equal(t, sql.NullBool{Bool: true, Valid: true}, model.Deleted)
+
+	ids = nil
+	err = db.NewSelect().Model((*Model)(nil)).Column("id").WhereDeleted().Order("id").Scan(ctx, &ids)
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 3}, ids)
+}
+
+func testDropIndex(t *testing.T, db *bun.DB) {
+	type Model struct {
+		ID  int64 `bun:",pk"`
+		Str string
+	}
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	_, err = db.NewCreateIndex().Model((*Model)(nil)).Index("models_str_idx").Column("str").Exec(ctx)
+	require.NoError(t, err)
+
+	q := db.NewDropIndex().Model((*Model)(nil)).Index("?", bun.Ident("models_str_idx"))
+
+	// The template keeps the placeholder and formats back to the same query.
+	// The nop formatter has no dialect, so the ON clause of MySQL and MSSQL
+	// is only part of the formatted query.
+	tmpl, err := q.AppendQuery(schema.NewNopFormatter(), nil)
+	require.NoError(t, err)
+	require.Equal(t, "DROP INDEX ?", string(tmpl))
+
+	query, err := q.AppendQuery(db.Formatter(), nil)
+	require.NoError(t, err)
+	formatted := db.Formatter().FormatQuery(string(tmpl), bun.Ident("models_str_idx"))
+	require.True(t, strings.HasPrefix(string(query), formatted), "%s", query)
+
+	_, err = q.Exec(ctx)
+	require.NoError(t, err)
+
+	// The index is gone, so it can be created again.
+	_, err = db.NewCreateIndex().Model((*Model)(nil)).Index("models_str_idx").Column("str").Exec(ctx)
+	require.NoError(t, err)
+}
+
+func testTextMarshalerRoundTrip(t *testing.T, db *bun.DB) {
+	type Model struct {
+		ID       int64      `bun:",pk"`
+		Point    textPoint  `bun:"type:varchar"`
+		PointPtr *textPoint `bun:"type:varchar"`
+		Level    textLevel
+	}
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	model := &Model{
+		ID:       1,
+		Point:    textPoint{X: "1", Y: "2"},
+		PointPtr: &textPoint{X: "3", Y: "4"},
+		Level:    1,
+	}
+	_, err = db.NewInsert().Model(model).Exec(ctx)
+	require.NoError(t, err)
+
+	var str string
+	err = db.NewSelect().Model((*Model)(nil)).Column("point").Scan(ctx, &str)
+	require.NoError(t, err)
+	require.Equal(t, "1:2", str)
+
+	// Named integer types are stored as integers.
+	var level int64
+	err = db.NewSelect().Model((*Model)(nil)).Column("level").Scan(ctx, &level)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), level)
+
+	got := new(Model)
+	err = db.NewSelect().Model(got).Where("id = 1").Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, model, got)
+
+	if db.Dialect().Name() == dialect.MSSQL {
+		return
+	}
+
+	// JSON columns are appended and scanned as JSON.
+	type JSONModel struct {
+		ID    int64     `bun:",pk"`
+		Point textPoint `bun:"type:json"`
+	}
+
+	err = db.ResetModel(ctx, (*JSONModel)(nil))
+	require.NoError(t, err)
+
+	jsonModel := &JSONModel{ID: 1, Point: textPoint{X: "1", Y: "2"}}
+	_, err = db.NewInsert().Model(jsonModel).Exec(ctx)
+	require.NoError(t, err)
+
+	gotJSON := new(JSONModel)
+	err = db.NewSelect().Model(gotJSON).Where("id = 1").Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, jsonModel, gotJSON)
+}
+
+// binaryConfig only implements UnmarshalBinary, so it is appended and scanned as JSON.
+type binaryConfig struct {
+	A, B string
+}
+
+func (c *binaryConfig) UnmarshalBinary(b []byte) error {
+	return fmt.Errorf("bad cfg %q", b)
+}
+
+func testTextMarshalerDefaultJSON(t *testing.T, db *bun.DB) {
+	type Model struct {
+		ID     int64 `bun:",pk"`
+		Point  textPoint
+		Config binaryConfig `bun:"type:varchar"`
+	}
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	model := &Model{
+		ID:     1,
+		Point:  textPoint{X: "1", Y: "2"},
+		Config: binaryConfig{A: "x", B: "y"},
+	}
+	_, err = db.NewInsert().Model(model).Exec(ctx)
+	require.NoError(t, err)
+
+	// Fields without an explicit SQL type are still stored as JSON.
+	var str string
+	err = db.NewSelect().Model((*Model)(nil)).Column("point").Scan(ctx, &str)
+	require.NoError(t, err)
+	require.Equal(t, `"1:2"`, str)
+
+	got := new(Model)
+	err = db.NewSelect().Model(got).Where("id = 1").Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, model, got)
+}
+
+func testEmptyInFalseLiteral(t *testing.T, db *bun.DB) {
+	var buf bytes.Buffer
+	prevOutput := internal.Warn.Writer()
+	t.Cleanup(func() {
+		internal.Warn.SetOutput(prevOutput)
+	})
+	internal.Warn.SetOutput(&buf)
+
+	var num int
+	err := db.WithEmptyInPolicy(bun.EmptyInFalseLiteral).
+		NewSelect().
+		ColumnExpr("1").
+		Where("1 IN (?)", bun.In([]int{})).
+		Scan(ctx, &num)
+	require.Equal(t, sql.ErrNoRows, err)
+	require.Empty(t, buf.String())
+}
+
+func testSoftDeleteReturning(t *testing.T, db *bun.DB) {
+	if !db.Dialect().Features().Has(feature.Returning) {
+		t.Skip()
+		return
+	}
+
+	type Model struct {
+		ID        int64     `bun:",pk"`
+		DeletedAt time.Time `bun:",soft_delete,nullzero"`
+	}
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	model := &Model{ID: 1}
+	_, err = db.NewInsert().Model(model).Exec(ctx)
+	require.NoError(t, err)
+
+	_, err = db.NewDelete().Model(model).WherePK().Returning("deleted_at").Exec(ctx)
+	require.NoError(t, err)
+
+	var deletedAt time.Time
+	err = db.NewSelect().Model((*Model)(nil)).Column("deleted_at").WhereDeleted().Scan(ctx, &deletedAt)
+	require.NoError(t, err)
+	require.True(t, model.DeletedAt.Equal(deletedAt), "%s != %s", model.DeletedAt, deletedAt)
+}
+
+func testSoftDeleteUnixTime(t *testing.T, db *bun.DB) {
+	type Model struct {
+		ID        int64     `bun:",pk"`
+		DeletedAt time.Time `bun:",soft_delete,unixtime,nullzero"`
+	}
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	model := &Model{ID: 1}
+	_, err = db.NewInsert().Model(model).Exec(ctx)
+	require.NoError(t, err)
+
+	_, err = db.NewDelete().Model(model).WherePK().Exec(ctx)
+	require.NoError(t, err)
+	require.False(t, model.DeletedAt.IsZero())
+
+	count, err := db.NewSelect().Model((*Model)(nil)).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 0, count)
+
+	var deletedAt int64
+	err = db.NewSelect().Model((*Model)(nil)).Column("deleted_at").WhereDeleted().Scan(ctx, &deletedAt)
+	require.NoError(t, err)
+	require.Equal(t, model.DeletedAt.Unix(), deletedAt)
 }
 
 func testScanNullVar(t *testing.T, db *bun.DB) {
@@ -1551,3 +3132,179 @@ func testRunInTxAndSavepoint(t *testing.T, db *bun.DB) {
 	require.NoError(t, err)
 	require.Equal(t, 4, count)
 }
+
+func testIndexes(t *testing.T, db *bun.DB) {
+	type IndexModel struct {
+		ID  int64 `bun:",pk,autoincrement"`
+		Foo int64
+		Bar int64
+	}
+
+	err := db.ResetModel(ctx, (*IndexModel)(nil))
+	require.NoError(t, err)
+
+	_, err = db.NewCreateIndex().
+		Model((*IndexModel)(nil)).
+		Unique().
+		Index("index_models_foo_bar_idx").
+		Column("foo", "bar").
+		Exec(ctx)
+	require.NoError(t, err)
+
+	_, err = db.NewCreateIndex().
+		Model((*IndexModel)(nil)).
+		Index("index_models_bar_idx").
+		Column("bar").
+		Exec(ctx)
+	require.NoError(t, err)
+
+	indexes, err := db.Indexes(ctx, "index_models")
+	require.NoError(t, err)
+
+	byName := make(map[string]schema.IndexInfo)
+	for _, index := range indexes {
+		byName[index.Name] = index
+	}
+
+	require.Equal(t, schema.IndexInfo{
+		Name:    "index_models_foo_bar_idx",
+		Columns: []string{"foo", "bar"},
+		Unique:  true,
+	}, byName["index_models_foo_bar_idx"])
+	require.Equal(t, schema.IndexInfo{
+		Name:    "index_models_bar_idx",
+		Columns: []string{"bar"},
+		Unique:  false,
+	}, byName["index_models_bar_idx"])
+}
+
+func testExpressionIndexes(t *testing.T, db *bun.DB) {
+	if db.Dialect().Name() != dialect.SQLite {
+		t.Skip()
+	}
+
+	type Book struct {
+		ID   int64 `bun:",pk,autoincrement"`
+		Name string
+	}
+
+	err := db.ResetModel(ctx, (*Book)(nil))
+	require.NoError(t, err)
+
+	_, err = db.NewCreateIndex().
+		Model((*Book)(nil)).
+		Index("books_lower_name_idx").
+		ColumnExpr("lower(name)").
+		Column("id").
+		Exec(ctx)
+	require.NoError(t, err)
+
+	indexes, err := db.Indexes(ctx, "books")
+	require.NoError(t, err)
+	require.Equal(t, []schema.IndexInfo{{
+		Name:    "books_lower_name_idx",
+		Columns: []string{"", "id"},
+	}}, indexes)
+}
+
+func testWherePKSorted(t *testing.T, db *bun.DB) {
+	type Model struct {
+		ID  int64 `bun:",pk"`
+		Str string
+	}
+
+	models1 := []Model{{ID: 1}, {ID: 2}, {ID: 3}}
+	models2 := []Model{{ID: 3}, {ID: 1}, {ID: 2}}
+
+	q1 := db.NewSelect().Model(&models1).WherePKSorted().String()
+	q2 := db.NewSelect().Model(&models2).WherePKSorted().String()
+	require.Equal(t, q1, q2)
+
+	q2 = db.NewSelect().Model(&models2).WherePK().String()
+	require.NotEqual(t, q1, q2)
+}
+
+func testQueryCache(t *testing.T, db *bun.DB) {
+	type Model struct {
+		ID  int64 `bun:",pk,autoincrement"`
+		Str string
+	}
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	models := []Model{{Str: "a"}, {Str: "b"}, {Str: "c"}}
+	_, err = db.NewInsert().Model(&models).Exec(ctx)
+	require.NoError(t, err)
+
+	db = db.WithQueryCache(100)
+
+	// The first query is cached and the others only bind other values.
+	for _, want := range models {
+		got := new(Model)
+		err := db.NewSelect().
+			Model(got).
+			Where("? = ?", bun.Ident("str"), want.Str).
+			Where("id = ?0 OR id = ?0", want.ID).
+			Scan(ctx)
+		require.NoError(t, err)
+		require.Equal(t, want, *got)
+	}
+
+	// Model fields are not part of the cached query.
+	for _, want := range models {
+		got := &Model{ID: want.ID}
+		err := db.NewSelect().Model(got).Where("id = ?id").Scan(ctx)
+		require.NoError(t, err)
+		require.Equal(t, want, *got)
+	}
+
+	// In lists are formatted every time, because the length varies.
+	for i := range models {
+		var got []Model
+		err := db.NewSelect().
+			Model(&got).
+			Where("str IN (?)", bun.In([]string{models[0].Str, models[i].Str})).
+			Order("id").
+			Scan(ctx)
+		require.NoError(t, err)
+
+		want := []Model{models[0]}
+		if i > 0 {
+			want = append(want, models[i])
+		}
+		require.Equal(t, want, got)
+	}
+
+	type SoftModel struct {
+		ID        int64     `bun:",pk,autoincrement"`
+		DeletedAt time.Time `bun:",soft_delete,nullzero"`
+	}
+
+	err = db.ResetModel(ctx, (*SoftModel)(nil))
+	require.NoError(t, err)
+
+	softModels := []SoftModel{{}, {}}
+	_, err = db.NewInsert().Model(&softModels).Exec(ctx)
+	require.NoError(t, err)
+	_, err = db.NewDelete().Model(&softModels[0]).WherePK().Exec(ctx)
+	require.NoError(t, err)
+
+	// Per-request copies share the cache, which keeps queries
+	// with and without deleted rows apart.
+	for i := 0; i < 2; i++ {
+		for _, includeDeleted := range []bool{false, true} {
+			reqCtx := ctx
+			want := 1
+			if includeDeleted {
+				reqCtx = bun.WithIncludeDeleted(ctx)
+				want = 2
+			}
+
+			var got []SoftModel
+			err := db.WithContextFlags(reqCtx).NewSelect().Model(&got).Scan(ctx)
+			require.NoError(t, err)
+			require.Len(t, got, want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"net"
 	"reflect"
@@ -17,6 +18,7 @@ import (
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/pgdialect"
 	"github.com/uptrace/bun/driver/pgdriver"
+	"github.com/uptrace/bun/schema"
 )
 
 func TestPostgresArray(t *testing.T) {
@@ -463,6 +465,37 @@ func TestPostgresTimetz(t *testing.T) {
 	require.NotZero(t, tm)
 }
 
+func TestPostgresInterval(t *testing.T) {
+	type Model struct {
+		ID       int64 `bun:",pk,autoincrement"`
+		Interval pgdialect.Interval
+	}
+
+	db := pg(t)
+	defer db.Close()
+
+	var interval pgdialect.Interval
+	err := db.NewSelect().ColumnExpr("'02:03:04'::interval").Scan(ctx, &interval)
+	require.NoError(t, err)
+	require.Equal(t, 2*time.Hour+3*time.Minute+4*time.Second, interval.Duration())
+
+	err = db.NewSelect().ColumnExpr("'1 day 02:03:04'::interval").Scan(ctx, &interval)
+	require.NoError(t, err)
+	require.Equal(t, 26*time.Hour+3*time.Minute+4*time.Second, interval.Duration())
+
+	err = db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	model1 := &Model{Interval: pgdialect.Interval(50*time.Hour + 1500*time.Millisecond)}
+	_, err = db.NewInsert().Model(model1).Exec(ctx)
+	require.NoError(t, err)
+
+	model2 := new(Model)
+	err = db.NewSelect().Model(model2).Where("id = ?", model1.ID).Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, model1, model2)
+}
+
 func TestPostgresOnConflictDoUpdate(t *testing.T) {
 	type Model struct {
 		ID        int64 `bun:",pk,autoincrement"`
@@ -500,6 +533,43 @@ func TestPostgresOnConflictDoUpdate(t *testing.T) {
 	}
 }
 
+func TestPostgresOnConflictReturnInserted(t *testing.T) {
+	type Model struct {
+		ID       int64 `bun:",pk,autoincrement"`
+		Name     string
+		Inserted bool `bun:",scanonly"`
+	}
+
+	ctx := context.Background()
+
+	db := pg(t)
+	defer db.Close()
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	model := &Model{ID: 1, Name: "foo"}
+	_, err = db.NewInsert().
+		Model(model).
+		On("CONFLICT (id) DO UPDATE").
+		Set("name = EXCLUDED.name").
+		OnConflictReturnInserted().
+		Exec(ctx)
+	require.NoError(t, err)
+	require.True(t, model.Inserted)
+
+	model = &Model{ID: 1, Name: "bar"}
+	_, err = db.NewInsert().
+		Model(model).
+		On("CONFLICT (id) DO UPDATE").
+		Set("name = EXCLUDED.name").
+		OnConflictReturnInserted().
+		Exec(ctx)
+	require.NoError(t, err)
+	require.False(t, model.Inserted)
+	require.Equal(t, "bar", model.Name)
+}
+
 func TestPostgresOnConflictDoUpdateIdentity(t *testing.T) {
 	type Model struct {
 		ID        int64 `bun:",pk,identity"`
@@ -721,6 +791,375 @@ func TestPostgresHStoreQuote(t *testing.T) {
 	require.Equal(t, wanted, m)
 }
 
+func TestPostgresHStoreNull(t *testing.T) {
+	db := pg(t)
+	defer db.Close()
+
+	_, err := db.Exec(`CREATE EXTENSION IF NOT EXISTS HSTORE;`)
+	require.NoError(t, err)
+
+	m := make(map[string]string)
+	err = db.NewSelect().
+		ColumnExpr(`'a=>NULL, b=>"c\"d", e=>NULL'::hstore`).
+		Scan(ctx, pgdialect.HStore(&m))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"a": "", "b": `c"d`, "e": ""}, m)
+
+	err = db.NewSelect().
+		ColumnExpr(`''::hstore`).
+		Scan(ctx, pgdialect.HStore(&m))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{}, m)
+}
+
+func TestPostgresScanNumericDecimal(t *testing.T) {
+	db := pg(t)
+	defer db.Close()
+
+	schema.RegisterScanner((*decimal)(nil), scanDecimal)
+
+	type Model struct {
+		Num decimal
+	}
+
+	model := new(Model)
+	err := db.NewSelect().
+		ColumnExpr("?::numeric AS num", "12345678901234567890.123456789").
+		Scan(ctx, model)
+	require.NoError(t, err)
+	require.Equal(t, "12345678901234567890.123456789", model.Num.String())
+}
+
+func TestPostgresExplainAnalyze(t *testing.T) {
+	db := pg(t)
+	defer db.Close()
+
+	plan, err := db.NewSelect().
+		ColumnExpr("generate_series(1, 10)").
+		ExplainAnalyze(ctx, bun.ExplainOptions{Buffers: true})
+	require.NoError(t, err)
+	require.NotEmpty(t, plan)
+	require.Contains(t, plan, "actual time")
+
+	plan, err = db.NewSelect().
+		ColumnExpr("1").
+		ExplainAnalyze(ctx, bun.ExplainOptions{Format: "json"})
+	require.NoError(t, err)
+	require.True(t, json.Valid([]byte(plan)))
+}
+
+func TestPostgresUpdateSetJSON(t *testing.T) {
+	type Model struct {
+		ID   int64                  `bun:",pk,autoincrement"`
+		Data map[string]interface{} `bun:"type:jsonb"`
+	}
+
+	db := pg(t)
+	defer db.Close()
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	model := &Model{Data: map[string]interface{}{
+		"settings": map[string]interface{}{"theme": "light", "lang": "en"},
+	}}
+	_, err = db.NewInsert().Model(model).Exec(ctx)
+	require.NoError(t, err)
+
+	_, err = db.NewUpdate().
+		Model(model).
+		SetJSON("data", "settings.theme", "dark").
+		WherePK().
+		Exec(ctx)
+	require.NoError(t, err)
+
+	model2 := new(Model)
+	err = db.NewSelect().Model(model2).Where("id = ?", model.ID).Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"settings": map[string]interface{}{"theme": "dark", "lang": "en"},
+	}, model2.Data)
+}
+
+func TestPostgresWithOrdinality(t *testing.T) {
+	db := pg(t)
+	defer db.Close()
+
+	type Row struct {
+		Val string
+		Idx int64
+	}
+
+	var rows []Row
+	err := db.NewSelect().
+		Column("t.val", "t.idx").
+		TableExpr("?", bun.Func("unnest(?)", pgdialect.Array([]string{"a", "b", "c"})).
+			WithOrdinality().
+			As("t", "val", "idx")).
+		OrderExpr("t.idx").
+		Scan(ctx, &rows)
+	require.NoError(t, err)
+	require.Equal(t, []Row{{"a", 1}, {"b", 2}, {"c", 3}}, rows)
+}
+
+func TestPostgresDeleteReturningCTE(t *testing.T) {
+	type Model struct {
+		ID  int64 `bun:",pk"`
+		Str string
+	}
+
+	type Archive struct {
+		ID  int64 `bun:",pk"`
+		Str string
+	}
+
+	db := pg(t)
+	defer db.Close()
+
+	for _, model := range []interface{}{(*Model)(nil), (*Archive)(nil)} {
+		err := db.ResetModel(ctx, model)
+		require.NoError(t, err)
+	}
+
+	models := []Model{{ID: 1, Str: "one"}, {ID: 2, Str: "two"}, {ID: 3, Str: "three"}}
+	_, err := db.NewInsert().Model(&models).Exec(ctx)
+	require.NoError(t, err)
+
+	res, err := db.NewInsert().
+		With("deleted", db.NewDelete().
+			Model((*Model)(nil)).
+			Where("id < ?", 3).
+			Returning("*")).
+		Model((*Archive)(nil)).
+		Table("deleted").
+		Exec(ctx)
+	require.NoError(t, err)
+
+	n, err := res.RowsAffected()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), n)
+
+	var archived []Archive
+	err = db.NewSelect().Model(&archived).Order("id").Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []Archive{{ID: 1, Str: "one"}, {ID: 2, Str: "two"}}, archived)
+
+	count, err := db.NewSelect().Model((*Model)(nil)).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+}
+
+func TestPostgresWhereRaw(t *testing.T) {
+	type Model struct {
+		ID   int64                  `bun:",pk,autoincrement"`
+		Data map[string]interface{} `bun:"type:jsonb"`
+	}
+
+	db := pg(t)
+	defer db.Close()
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	models := []Model{
+		{Data: map[string]interface{}{"color": "red"}},
+		{Data: map[string]interface{}{"size": 10}},
+	}
+	_, err = db.NewInsert().Model(&models).Exec(ctx)
+	require.NoError(t, err)
+
+	var found []Model
+	err = db.NewSelect().Model(&found).WhereRaw(`data ? 'color'`).Scan(ctx)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, models[0].ID, found[0].ID)
+}
+
+func TestPostgresCreateIndexComment(t *testing.T) {
+	type Model struct {
+		ID  int64 `bun:",pk,autoincrement"`
+		Str string
+	}
+
+	db := pg(t)
+	defer db.Close()
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	q := db.NewCreateIndex().
+		Model((*Model)(nil)).
+		Index("models_str_idx").
+		Column("str").
+		Comment("lookup by str")
+
+	comment, err := q.CommentQuery().AppendQuery(db.Formatter(), nil)
+	require.NoError(t, err)
+	require.Equal(t, `COMMENT ON INDEX "models_str_idx" IS 'lookup by str'`, string(comment))
+
+	_, err = q.Exec(ctx)
+	require.NoError(t, err)
+
+	var desc string
+	err = db.NewSelect().
+		ColumnExpr("obj_description(?::regclass, 'pg_class')", "models_str_idx").
+		Scan(ctx, &desc)
+	require.NoError(t, err)
+	require.Equal(t, "lookup by str", desc)
+}
+
+func TestPostgresSelectIntoTable(t *testing.T) {
+	type Model struct {
+		ID  int64 `bun:",pk,autoincrement"`
+		Str string
+	}
+
+	db := pg(t)
+	defer db.Close()
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	_, err = db.NewDropTable().Table("models_archive").IfExists().Exec(ctx)
+	require.NoError(t, err)
+
+	models := []Model{{Str: "a"}, {Str: "b"}, {Str: "c"}}
+	_, err = db.NewInsert().Model(&models).Exec(ctx)
+	require.NoError(t, err)
+
+	_, err = db.NewSelect().
+		Model((*Model)(nil)).
+		Where("id > ?", 1).
+		IntoTable("models_archive").
+		Exec(ctx)
+	require.NoError(t, err)
+
+	n, err := db.NewSelect().Table("models_archive").Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	_, err = db.NewDropTable().Table("models_archive").Exec(ctx)
+	require.NoError(t, err)
+}
+
+func TestPostgresTrigramSimilar(t *testing.T) {
+	type Model struct {
+		ID   int64 `bun:",pk,autoincrement"`
+		Name string
+	}
+
+	db := pg(t)
+	defer db.Close()
+
+	_, err := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm")
+	require.NoError(t, err)
+
+	err = db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	models := []Model{{Name: "Jonathon"}, {Name: "Jonathan"}, {Name: "Jane"}}
+	_, err = db.NewInsert().Model(&models).Exec(ctx)
+	require.NoError(t, err)
+
+	var names []string
+	err = db.NewSelect().
+		Model((*Model)(nil)).
+		Column("name").
+		WhereTrigramSimilar("name", "Jonathan", 0.4).
+		OrderTrigramSimilarity("name", "Jonathan").
+		Scan(ctx, &names)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Jonathan", "Jonathon"}, names)
+}
+
+func TestPostgresDeferrableUniqueConstraint(t *testing.T) {
+	type Model struct {
+		ID  int64 `bun:",pk,autoincrement"`
+		Pos int64
+	}
+
+	db := pg(t)
+	defer db.Close()
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	_, err = db.NewAddUniqueConstraint().
+		Model((*Model)(nil)).
+		Constraint("models_pos_key").
+		Column("pos").
+		InitiallyDeferred().
+		Exec(ctx)
+	require.NoError(t, err)
+
+	models := []Model{{Pos: 1}, {Pos: 2}}
+	_, err = db.NewInsert().Model(&models).Exec(ctx)
+	require.NoError(t, err)
+
+	// Swapping positions temporarily violates the constraint,
+	// which is only checked on commit.
+	err = db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewUpdate().Model((*Model)(nil)).
+			Set("pos = 2").Where("id = ?", models[0].ID).Exec(ctx); err != nil {
+			return err
+		}
+		_, err := tx.NewUpdate().Model((*Model)(nil)).
+			Set("pos = 1").Where("id = ?", models[1].ID).Exec(ctx)
+		return err
+	})
+	require.NoError(t, err)
+
+	err = db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().Model((*Model)(nil)).
+			Set("pos = 1").Where("id = ?", models[0].ID).Exec(ctx)
+		return err
+	})
+	require.Error(t, err)
+}
+
+func TestPostgresOnConflictDoUpdateWhere(t *testing.T) {
+	type Item struct {
+		ID        int64 `bun:",pk"`
+		Name      string
+		UpdatedAt time.Time
+	}
+
+	db := pg(t)
+	defer db.Close()
+
+	err := db.ResetModel(ctx, (*Item)(nil))
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	_, err = db.NewInsert().Model(&Item{ID: 1, Name: "old", UpdatedAt: now}).Exec(ctx)
+	require.NoError(t, err)
+
+	upsert := func(item *Item) {
+		_, err := db.NewInsert().
+			Model(item).
+			OnConflictDoUpdate().
+			Set("name = EXCLUDED.name").
+			Set("updated_at = EXCLUDED.updated_at").
+			Where("EXCLUDED.updated_at > item.updated_at").
+			Exec(ctx)
+		require.NoError(t, err)
+	}
+
+	// The incoming row is older so the existing row is kept.
+	upsert(&Item{ID: 1, Name: "stale", UpdatedAt: now.Add(-time.Hour)})
+
+	item := new(Item)
+	err = db.NewSelect().Model(item).Where("id = 1").Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "old", item.Name)
+
+	upsert(&Item{ID: 1, Name: "new", UpdatedAt: now.Add(time.Hour)})
+
+	err = db.NewSelect().Model(item).Where("id = 1").Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "new", item.Name)
+}
+
 func TestPostgresSkipupdateField(t *testing.T) {
 	type Model struct {
 		ID        int64 `bun:",pk,autoincrement"`
@@ -765,3 +1204,151 @@ func TestPostgresSkipupdateField(t *testing.T) {
 
 	require.NotEqual(t, model.CreatedAt.UTC(), model_.CreatedAt.UTC())
 }
+
+func TestPostgresCreateIndexWithNewConn(t *testing.T) {
+	type Model struct {
+		ID   int64 `bun:",pk,autoincrement"`
+		Name string
+	}
+
+	db := pg(t)
+	defer db.Close()
+
+	_, err := db.NewDropTable().Model((*Model)(nil)).IfExists().Exec(ctx)
+	require.NoError(t, err)
+
+	_, err = db.NewCreateTable().Model((*Model)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	_, err = tx.NewCreateIndex().
+		Model((*Model)(nil)).
+		Index("models_name_idx").
+		Column("name").
+		Concurrently().
+		Exec(ctx)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cannot run inside a transaction block")
+
+	require.NoError(t, tx.Rollback())
+
+	tx, err = db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	_, err = tx.NewCreateIndex().
+		Model((*Model)(nil)).
+		Index("models_name_idx").
+		Column("name").
+		Concurrently().
+		WithNewConn().
+		Exec(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, tx.Rollback())
+
+	indexes, err := db.Indexes(ctx, "models")
+	require.NoError(t, err)
+
+	var found bool
+	for _, index := range indexes {
+		if index.Name == "models_name_idx" {
+			found = true
+		}
+	}
+	require.True(t, found, "the index must survive the rollback")
+}
+
+func TestPostgresExpressionIndex(t *testing.T) {
+	type Model struct {
+		ID   int64 `bun:",pk,autoincrement"`
+		Name string
+	}
+
+	db := pg(t)
+	defer db.Close()
+
+	err := db.ResetModel(ctx, (*Model)(nil))
+	require.NoError(t, err)
+
+	_, err = db.NewCreateIndex().
+		Model((*Model)(nil)).
+		Unique().
+		Index("models_lower_name_idx").
+		ColumnExpr("lower(name)").
+		Column("id").
+		Exec(ctx)
+	require.NoError(t, err)
+
+	indexes, err := db.Indexes(ctx, "models")
+	require.NoError(t, err)
+
+	var found bool
+	for _, index := range indexes {
+		if index.Name == "models_lower_name_idx" {
+			found = true
+			require.Equal(t, []string{"lower(name)", "id"}, index.Columns)
+			require.True(t, index.Unique)
+		}
+	}
+	require.True(t, found, "expression indexes must be listed")
+}
+
+func TestPostgresDeferConstraints(t *testing.T) {
+	db := pg(t)
+	defer db.Close()
+
+	var queries []string
+	hook := &queryHook{
+		beforeQuery: func(ctx context.Context, event *bun.QueryEvent) context.Context {
+			queries = append(queries, event.Query)
+			return ctx
+		},
+	}
+	db.AddQueryHook(hook)
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	err = tx.DeferConstraints(ctx)
+	require.NoError(t, err)
+
+	err = tx.DeferConstraints(ctx, "fk_one", "fk_two")
+	require.Error(t, err) // the constraints don't exist
+
+	require.Equal(t, []string{
+		"BEGIN",
+		`SET CONSTRAINTS ALL DEFERRED`,
+		`SET CONSTRAINTS "fk_one", "fk_two" DEFERRED`,
+	}, queries)
+}
+
+func TestPostgresCreateIndexDoBlock(t *testing.T) {
+	type Model struct {
+		ID   int64 `bun:",pk,autoincrement"`
+		Name string
+	}
+
+	db := pg(t)
+	defer db.Close()
+
+	_, err := db.NewDropTable().Model((*Model)(nil)).IfExists().Exec(ctx)
+	require.NoError(t, err)
+
+	_, err = db.NewCreateTable().Model((*Model)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err = db.NewCreateIndex().
+			Model((*Model)(nil)).
+			Index("models_name_idx").
+			Column("name").
+			IfNotExistsDoBlock().
+			Exec(ctx)
+		require.NoError(t, err)
+	}
+}
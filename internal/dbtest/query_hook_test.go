@@ -71,6 +71,30 @@ func testQueryHook(t *testing.T, dbName string, db *bun.DB) {
 		require.Equal(t, 1, num)
 		hook.require(t)
 	}
+
+	{
+		hook.reset()
+		hook.beforeQuery = func(
+			ctx context.Context, event *bun.QueryEvent,
+		) context.Context {
+			require.Equal(t, "SELECT 1 AS num UNION ALL SELECT 2", string(event.Query))
+			return ctx
+		}
+
+		rows, err := db.NewSelect().ColumnExpr("1 AS num UNION ALL SELECT 2").Rows(ctx)
+		require.NoError(t, err)
+		hook.require(t)
+
+		var nums []int
+		for rows.Next() {
+			var num int
+			require.NoError(t, rows.Scan(&num))
+			nums = append(nums, num)
+		}
+		require.NoError(t, rows.Err())
+		require.NoError(t, rows.Close())
+		require.Equal(t, []int{1, 2}, nums)
+	}
 }
 
 type queryHook struct {
@@ -1,16 +1,23 @@
 package dbtest_test
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/bradleyjkemp/cupaloy"
+	"github.com/stretchr/testify/require"
 
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+	"github.com/uptrace/bun/dialect/feature"
 	"github.com/uptrace/bun/schema"
 )
 
@@ -902,11 +909,844 @@ func TestQuery(t *testing.T) {
 			}
 			return db.NewCreateTable().Model(new(User))
 		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model(new(Model)).
+				WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+					return q.Where("id = 1").WhereOr("id = 2")
+				}).
+				Where("str IS NOT NULL").
+				Order("id").
+				Limit(10)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewCreateIndex().
+				Index("title_idx").
+				Table("films").
+				Column("title").
+				WhereGroup(" AND ", func(q *bun.CreateIndexQuery) *bun.CreateIndexQuery {
+					return q.Where("kind = 'comedy'").WhereOr("kind = 'drama'")
+				}).
+				Where("deleted_at IS NULL")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			q1 := db.NewSelect().Where("id > ?", 1).Where("id < ?", 10)
+			q2 := db.NewSelect().Where("str = ?", "foo").WhereOr("str IS NULL")
+			return db.NewSelect().
+				Model(new(Model)).
+				WhereOrQuery(q1).
+				WhereOrQuery(q2)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			q1 := db.NewDelete().Where("id > ?", 1).Where("id < ?", 10)
+			q2 := db.NewDelete().Where("str = ?", "foo").Where("id = ?", 100)
+			return db.NewDelete().
+				Model(new(Model)).
+				Where("str IS NOT NULL").
+				WhereOrQuery(q1).
+				WhereOrQuery(q2)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewInsert().
+				Model(&Model{42, "hello"}).
+				On("CONFLICT (id) DO UPDATE").
+				Set("str = EXCLUDED.str").
+				OnConflictReturnInserted()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model(new(SoftDelete1)).
+				Where("name LIKE ?", "foo%").
+				WhereOr("name LIKE ?", "bar%")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model(new(Model)).
+				WhereIf(true, "id = ?", 1).
+				WhereIf(false, "id = ?", 2).
+				WhereOrIf(false, "str = ?", "foo").
+				WhereOrIf(true, "str IS NULL")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model(new(Model)).
+				WhereIf(false, "id = ?", 1).
+				WhereOrIf(false, "str = ?", "foo")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			models := []Model{{ID: 3}, {ID: 1}, {ID: 2}}
+			return db.NewSelect().Model(&models).WherePKSorted()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewDelete().
+				Model(new(Model)).
+				TableExpr("stories AS story").
+				Where("story.model_id = model.id").
+				Where("story.name = ?", "foo")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewDelete().
+				Table("models", "stories").
+				Where("stories.model_id = models.id")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.WithEmptyInPolicy(bun.EmptyInError).
+				NewSelect().
+				Model(new(Model)).
+				Where("id IN (?)", bun.In([]int{}))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.WithEmptyInPolicy(bun.EmptyInFalseLiteral).
+				NewSelect().
+				Model(new(Model)).
+				Where("str IS NOT NULL").
+				Where("id IN (?)", bun.In([]int{}))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.WithEmptyInPolicy(bun.EmptyInFalseLiteral).
+				NewSelect().
+				Model(new(Model)).
+				Where("id IN (?)", bun.In([]int{})).
+				WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+					return q.Where("id IN (?)", bun.In([]int{})).WhereOr("str = ?", "foo")
+				}).
+				WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+					return q.Where("id IN (?)", bun.In([]int{}))
+				})
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.WithEmptyInPolicy(bun.EmptyInFalseLiteral).
+				NewDelete().
+				Model(new(Model)).
+				Where("id IN (?)", bun.In([]int{}))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			models := []Model{}
+			return db.WithEmptyInPolicy(bun.EmptyInFalseLiteral).
+				NewDelete().
+				Model(&models).
+				WherePK()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewInsert().Model(&Model{Str: "hello"}).ReturningAll()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewUpdate().Model(&Model{ID: 42, Str: "hello"}).WherePK().ReturningAll()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().ColumnExpr("'a;b' AS str").Model((*Model)(nil)).Where(`"id;" = 1`)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().Model((*Model)(nil)).Where("id = 1; DROP TABLE models")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewUpdate().Model((*Model)(nil)).Set("str = 'x'; DELETE FROM models").WherePK()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewRenameIndex().Model((*Model)(nil)).Rename("old_idx", "new_idx")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewRenameIndex().IfExists().Rename("old_idx", "new_idx")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model(new(SoftDelete1)).
+				ColumnExpr("count(*)").
+				Group("id").
+				Having("count(*) > ?", 1)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().Model((*Model)(nil)).Only()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewUpdate().Model(&Model{ID: 42, Str: "hello"}).WherePK().Only()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewDelete().Model(&Model{ID: 42}).WherePK().Only()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewUpdate().
+				Model(&Model{ID: 42}).
+				SetQuery("str", db.NewSelect().
+					ColumnExpr("max(story.name)").
+					TableExpr("stories AS story").
+					Where("story.model_id = model.id")).
+				WherePK()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			q := db.NewSelect().
+				Model((*Model)(nil)).
+				Where("str = ?", "foo").
+				Where("str <> ?", "bar").
+				Where("id = ?", 42)
+
+			// Move the last condition to the front.
+			where := q.Conditions()
+			last := where[len(where)-1]
+			where = append([]schema.QueryWithSep{last}, where[:len(where)-1]...)
+			where[0].Sep, where[1].Sep = "", last.Sep
+			return q.SetConditions(where)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().Model((*Model)(nil)).TableSample("bernoulli", 10)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().Model((*Model)(nil)).TableSample("random", 10)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			q1 := db.NewSelect().Model(new(Model)).Where("id < ?", 10)
+			q2 := db.NewSelect().Model(new(Model)).Where("id > ?", 20)
+			return q1.UnionAll(q2)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			q1 := db.NewSelect().Model(new(Model))
+			q2 := db.NewSelect().Model(new(Model)).Where("str = ?", "hello")
+			return q1.Except(q2)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			q1 := db.NewSelect().Model(new(Model)).Where("id < ?", 10)
+			q2 := db.NewSelect().Model(new(Model)).Where("id > ?", 20)
+			return db.NewSelect().
+				TableExpr("(?) AS u", q1.UnionAll(q2)).
+				Order("id").
+				Limit(10)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model((*Model)(nil)).
+				Column("id", "str").
+				Join("JOIN stories AS story ON story.model_id = model.id")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model((*Model)(nil)).
+				Column("id").
+				ColumnExpr("?", bun.Case().
+					When("id < ?", []interface{}{10}, "?", []interface{}{"small"}).
+					When("id < ?", []interface{}{100}, "?", []interface{}{"medium"}).
+					Else("?", "large").
+					As("size"))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			cte := db.NewSelect().Model((*Model)(nil)).ColumnExpr("id, str AS name")
+			return db.NewSelect().
+				With("cte", cte).
+				Table("cte").
+				Column("id", "name", "cte.name")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewCreateIndex().Model((*Model)(nil)).Column("id", "str")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewCreateIndex().
+				Table("organization_memberships").
+				Column("organization_identifier", "membership_role_name", "created_at")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewCreateIndex().
+				Model((*Model)(nil)).
+				Index("str_idx").
+				Column("str").
+				IfNotExistsDoBlock()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().Table("users").Schema("tenant1")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.WithSchema("tenant1").NewUpdate().Model(&Model{ID: 42, Str: "hello"}).WherePK()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.WithSchema("tenant1").NewSelect().
+				Model((*Model)(nil)).
+				Schema("tenant2")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewInsert().
+				Model(&Model{ID: 42, Str: "hello"}).
+				On("CONFLICT (?) DO NOTHING", bun.Ident("str")).
+				OnConflictWhere("? IS NULL", bun.Ident("deleted_at"))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewInsert().
+				Model(&Model{ID: 42, Str: "hello"}).
+				On("CONFLICT (str) DO UPDATE").
+				OnConflictWhere("deleted_at IS NULL").
+				Set("str = EXCLUDED.str")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewDelete().Model(new(SoftDelete1)).WherePK().SetDeletedAt("now()")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			f := bun.NewFilter().
+				Where("tenant_id = ?", 1).
+				WhereGroup(" AND ", func(f *bun.Filter) *bun.Filter {
+					return f.Where("status = ?", "draft").WhereOr("status = ?", "archived")
+				})
+
+			q := db.NewSelect().Model(new(Model)).Where("id > ?", 10)
+			f.ApplyTo(q.QueryBuilder())
+			return q
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			f := bun.NewFilter().
+				Where("tenant_id = ?", 1).
+				WhereGroup(" AND ", func(f *bun.Filter) *bun.Filter {
+					return f.Where("status = ?", "draft").WhereOr("status = ?", "archived")
+				})
+
+			q := db.NewDelete().Model(new(Model))
+			f.ApplyTo(q.QueryBuilder())
+			return q
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model(new(Model)).
+				WhereSubAll("price", ">", db.NewSelect().Table("discounts").Column("price"))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewDelete().
+				Model(new(Model)).
+				WhereSubAny("id", "=", db.NewSelect().Table("expired").Column("model_id"))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model(new(Model)).
+				WhereSubAny("id", "IN", db.NewSelect().Table("expired").Column("model_id"))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewAlterColumn().Model(new(Model)).Column("str").SetDefault("?", "hello")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewAlterColumn().Model(new(Model)).Column("str").DropDefault()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.WithContextFlags(bun.WithIncludeDeleted(ctx)).NewSelect().Model(new(SoftDelete1))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.WithContextFlags(bun.WithIncludeDeleted(ctx)).NewSelect().Model(new(SoftDelete1)).WhereDeleted()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.WithContextFlags(ctx).NewSelect().Model(new(SoftDelete1))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			type User struct {
+				ID        int64 `bun:",pk,autoincrement"`
+				Email     string
+				Name      string
+				CreatedAt time.Time
+				UpdatedAt time.Time
+			}
+
+			return db.NewInsert().
+				Model(&User{ID: 1, Email: "a@b.c", Name: "name"}).
+				UpsertAllExcept([]string{"email"}, "created_at")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewInsert().
+				Model(&Model{ID: 1, Str: "hello"}).
+				On("CONFLICT (id) DO UPDATE").
+				On("CONFLICT (str) DO NOTHING")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewInsert().
+				Model(&Model{ID: 1, Str: "hello"}).
+				On("CONFLICT (?) DO NOTHING", bun.Ident("id")).
+				On("CONFLICT (?) DO NOTHING", bun.Ident("id"))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			ids := []int64{1, 2, 3}
+			next := func() (interface{}, bool) {
+				if len(ids) == 0 {
+					return nil, false
+				}
+				id := ids[0]
+				ids = ids[1:]
+				return id, true
+			}
+			return db.NewSelect().Model(new(Model)).WhereInFunc("id", next)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			var n int
+			next := func() (interface{}, bool) {
+				n++
+				return n, true
+			}
+			return db.NewDelete().Model(new(Model)).WhereInFunc("id", next)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewCreateTableAs("snapshot", db.NewSelect().Model(new(Model)).Where("id > ?", 10))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewCreateTableAs("snapshot", db.NewSelect().Model(new(Model))).
+				Temporary().
+				IfNotExists().
+				WithNoData()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			collation := "C"
+			switch db.Dialect().Name() {
+			case dialect.MySQL:
+				collation = "utf8mb4_bin"
+			case dialect.SQLite:
+				collation = "NOCASE"
+			case dialect.MSSQL:
+				collation = "Latin1_General_CS_AS"
+			}
+			return db.NewSelect().Model(new(Model)).WhereCollate("str", "=", "foo", collation)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().Model(new(Model)).WhereCollate("str", "=", "foo", `C" OR 1=1`)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model(new(Model)).
+				ColumnExpr("?", bun.Agg("count(*)").Filter("str = ?", "active").As("active_count")).
+				ColumnExpr("?", bun.Agg("count(*)").As("total"))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model(new(Model)).
+				WhereTupleGreater([]string{"str", "id"}, []interface{}{"foo", 42}).
+				OrderExpr("str, id").
+				Limit(10)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model(new(Model)).
+				WhereTupleLessOrEqual([]string{"str", "id"}, []interface{}{"foo", 42})
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model(new(Model)).
+				WhereTupleGreater([]string{"str", "id"}, []interface{}{"foo"})
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.WithTablePrefix("test_").NewSelect().
+				Model((*Model)(nil)).
+				Table("users")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.WithTablePrefix("test_").NewInsert().Model(&Model{ID: 42, Str: "hello"})
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.WithTablePrefix("test_").NewUpdate().Model(&Model{ID: 42, Str: "hello"}).WherePK()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.WithTablePrefix("test_").NewDelete().Model(&Model{ID: 42}).WherePK()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			db = db.WithTablePrefix("test_")
+			return db.NewSelect().
+				With("recent", db.NewSelect().Model((*Model)(nil)).Where("id > 1")).
+				Table("recent")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewUpdate().
+				Model((*Model)(nil)).
+				SetJSON("data", "tags.0", map[string]int{"n": 1}).
+				Where("id = 1")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model((*Model)(nil)).
+				WhereInValuesJoin("id", []int64{1, 2, 3})
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewDelete().
+				Model((*Model)(nil)).
+				WhereInValuesJoin("str", []string{"a", "b"})
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Column("id", "str").
+				Table("models").
+				OrderOrdinal(1, 2)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model((*Model)(nil)).
+				WhereTupleGreater([]string{"str", "id"}, []interface{}{nil, 1})
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().Model((*Model)(nil)).Limit(10).LimitAll()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().Model((*Model)(nil)).OrderExpr("id").LimitAll().Offset(20)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewAddColumn().
+				Model((*Model)(nil)).
+				AddColumn("status", "varchar(20)").
+				NotNull().
+				Default("?", "active")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Column("t.val", "t.idx").
+				TableExpr("?", bun.Func("generate_series(?, ?)", 10, 12).
+					WithOrdinality().
+					As("t", "val", "idx"))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewInsert().
+				With("deleted", db.NewDelete().
+					Model((*Model)(nil)).
+					Where("id < ?", 10).
+					Returning("*")).
+				Table("archive", "deleted")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				ColumnExpr("? AS num", bun.Cast("str", "integer")).
+				ColumnExpr("? AS total", bun.Cast("id + 1", "text")).
+				Model((*Model)(nil))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewAlterColumn().Model((*Model)(nil)).RenameColumn("str", "name")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewAlterColumn().
+				Model((*Model)(nil)).
+				RenameColumn("str", "name").
+				Type("varchar(255)")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewCreateIndex().
+				Model((*Model)(nil)).
+				Index("index_name").
+				Column("str").
+				FillFactor(70)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewCreateIndex().
+				Model((*Model)(nil)).
+				Index("index_name").
+				Column("str").
+				FillFactor(70).
+				StorageParam("deduplicate_items = off").
+				Where("id > 0")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewCreateIndex().
+				Model((*Model)(nil)).
+				Index("index_name").
+				Column("str").
+				FillFactor(5)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model((*Model)(nil)).
+				Where("id = ?", 1).
+				WhereRaw(`str::jsonb ? 'key'`)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewDelete().
+				Model((*Model)(nil)).
+				WhereRaw("str = '?'")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewCreateTable().Model((*Model)(nil)).Temp().OnCommit("DROP")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewCreateTable().Model((*Model)(nil)).OnCommit("DELETE ROWS")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			type User struct {
+				ID   int64 `bun:",pk,autoincrement,identity:always"`
+				Name string
+			}
+			return db.NewCreateTable().Model(new(User))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			type User struct {
+				ID     int64 `bun:",pk,identity"`
+				Parent int64 `bun:",pk"`
+			}
+			return db.NewCreateTable().Model(new(User))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewCreateIndex().
+				Model((*Model)(nil)).
+				Index("index_name").
+				Column("str").
+				Comment("it's an index")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model((*Model)(nil)).
+				WhereSTDWithin("model.location", -73.9857, 40.7484, 500)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewDelete().
+				Model((*Model)(nil)).
+				WhereSTDWithin("location", 200, 40, 500)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model((*Model)(nil)).
+				Where("id > ?", 10).
+				IntoTable("archive")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model((*Model)(nil)).
+				IntoTempTable("staging")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewUpdate().
+				Model((*Model)(nil)).
+				Set("str = ?", "hello").
+				SetColumn("id", "id + 1").
+				Where("id = 1").
+				ReturningChanged()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			type Model struct {
+				ID   int64 `bun:",pk,autoincrement"`
+				Name string
+				Age  int
+				Bio  string
+			}
+			return db.NewUpdate().
+				Model(&Model{ID: 1, Name: "John", Age: 30}).
+				Column("name", "age").
+				WherePK().
+				ReturningChanged()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewUpdate().
+				Model((*Model)(nil)).
+				Set("(id, str) = (1, 'a')").
+				Where("id = 1").
+				ReturningChanged()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model((*Model)(nil)).
+				WhereTrigramSimilar("str", "jon", 0.5).
+				OrderTrigramSimilarity("str", "jon").
+				Limit(10)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model((*Model)(nil)).
+				WhereTrigramSimilar("str", "it's", 0)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model((*Model)(nil)).
+				Where("id IN (?)", db.NewSelect().
+					Model((*Model)(nil)).
+					Column("id").
+					Order("id").
+					Limit(100))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewDelete().
+				Model((*Model)(nil)).
+				Where("id IN (?)", db.NewSelect().
+					Model((*Model)(nil)).
+					Column("id").
+					Order("id").
+					Limit(100).
+					Offset(10))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewAddUniqueConstraint().
+				Model((*Model)(nil)).
+				Constraint("models_str_key").
+				Column("str").
+				InitiallyDeferred()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewAddUniqueConstraint().
+				Model((*Model)(nil)).
+				Column("id", "str")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().Model(new(Model)).Column("id", "str").ExcludeColumn("str")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().Model(new(Model)).Column("id").ExcludeColumn("str")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().Model(new(Model)).ExcludeColumn("str").Column("str")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			type Model struct {
+				ID      int64
+				Deleted sql.NullBool `bun:",soft_delete:bool"`
+			}
+			return db.NewSelect().Model(new(Model))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			type Model struct {
+				ID      int64
+				Deleted sql.NullBool `bun:",soft_delete:bool"`
+			}
+			return db.NewSelect().Model(new(Model)).WhereDeleted()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			type Model struct {
+				ID      int64
+				Deleted sql.NullBool `bun:",soft_delete:bool"`
+			}
+			return db.NewDelete().Model(new(Model)).Where("id = 1")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			type Item struct {
+				ID        int64 `bun:",pk"`
+				Name      string
+				UpdatedAt time.Time
+			}
+			return db.NewInsert().
+				Model(&Item{ID: 1, Name: "hello"}).
+				OnConflictDoUpdate().
+				Set("name = EXCLUDED.name").
+				Set("updated_at = EXCLUDED.updated_at").
+				Where("EXCLUDED.updated_at > item.updated_at")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			type Item struct {
+				ID        int64  `bun:",pk"`
+				Name      string `bun:",unique"`
+				UpdatedAt time.Time
+			}
+			return db.NewInsert().
+				Model(&Item{ID: 1, Name: "hello"}).
+				OnConflictDoUpdate("name").
+				Where("EXCLUDED.updated_at > item.updated_at")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewDropIndex().Model((*Model)(nil)).Index("title_idx")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewDropIndex().
+				Model((*Model)(nil)).
+				ModelTableExpr("?", bun.Ident("other_models")).
+				Index("?", bun.Ident("title_idx")).
+				Cascade()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.WithEmptyInPolicy(bun.EmptyInFalseLiteral).
+				NewDelete().
+				Model(new(Model)).
+				Where("str = ?", "tenant").
+				Where("id IN (?)", bun.In([]int{}))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.WithEmptyInPolicy(bun.EmptyInFalseLiteral).
+				NewUpdate().
+				Model(new(Model)).
+				Set("str = ?", "hello").
+				Where("id IN (?)", bun.In([]int{}))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.WithSchema("tenant1").NewSelect().Model((*Story)(nil)).Relation("User")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.WithSchema("tenant1").NewCreateTable().Model((*Story)(nil)).WithForeignKeys()
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.WithEmptyInPolicy(bun.EmptyInFalseLiteral).
+				NewSelect().
+				Model(new(Model)).
+				Where("id NOT IN (?)", bun.In([]int{}))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.WithEmptyInPolicy(bun.EmptyInFalseLiteral).
+				NewSelect().
+				Model(new(Model)).
+				Where("str = ? OR id IN (?)", "owner", bun.In([]int(nil)))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.WithEmptyInPolicy(bun.EmptyInFalseLiteral).
+				NewSelect().
+				Model(new(Model)).
+				Where("? IN (?) AND ?TableAlias.str NOT IN (?)", bun.Ident("id"), bun.In([]int{}), bun.In([]string{})).
+				Where("str = ?", "foo")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.WithEmptyInPolicy(bun.EmptyInFalseLiteral).
+				NewSelect().
+				Model(new(Model)).
+				Where("id = ANY(?) OR id IN (?)", bun.In([]int{}), bun.In([]int{}))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.WithTablePrefix("test_").
+				NewDelete().
+				Table("models", "stories").
+				Where("test_stories.model_id = test_models.id")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewDelete().
+				TableExpr("models AS m").
+				TableExpr("stories AS s").
+				Where("s.model_id = m.id")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewDelete().
+				Model(new(Story)).
+				Join("JOIN users AS u").
+				JoinOn("u.id = story.user_id").
+				Where("u.name = ?", "spam")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			q1 := db.NewSelect().Model(new(Model)).Where("id < ?", 10)
+			q2 := db.NewSelect().Model(new(Model)).Where("id > ?", 20)
+			return q1.UnionAll(q2).Order("id DESC").Limit(10)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			q1 := db.NewSelect().Model(new(Model))
+			q2 := db.NewSelect().Model(new(Model)).Where("str = ?", "hello")
+			return q1.Except(q2).OrderExpr("str").Limit(5).Offset(10)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			q1 := db.NewSelect().Model(new(Model)).Order("id").Limit(1)
+			q2 := db.NewSelect().Model(new(Model)).Order("id DESC").Limit(1)
+			return q1.UnionAll(q2).Order("str")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model((*Model)(nil)).
+				Column("id").
+				ColumnExpr("?", bun.Case().As("size"))
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewInsert().
+				Model(&Model{ID: 42, Str: "hello"}).
+				On("CONFLICT (str)\n\tDO UPDATE SET str = ' DO ' || ?", "world").
+				OnConflictWhere("str <> ?", "do")
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewInsert().
+				Model(&Model{ID: 42, Str: "hello"}).
+				On("CONFLICT (?) DO UPDATE SET str = ?", bun.Ident("str"), "world").
+				OnConflictWhere("id > ?", 0)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().Model((*Model)(nil)).TableSample("bernoulli", 150)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().Model((*Model)(nil)).TableSample("system", -1)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().
+				Model((*Model)(nil)).
+				Where("str = $$a;b$$").
+				Where("str = $tag$c;$$d$tag$").
+				Where(`str = E'it\'s; fine'`)
+		},
+		func(db *bun.DB) schema.QueryAppender {
+			return db.NewSelect().Model((*Model)(nil)).Where("str = $$a$$; DROP TABLE models")
+		},
 	}
 
 	timeRE := regexp.MustCompile(`'2\d{3}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(\.\d+)?(\+\d{2}:\d{2})?'`)
 
 	testEachDB(t, func(t *testing.T, dbName string, db *bun.DB) {
+		cachedDB := db.WithQueryCache(1000)
+
 		for i, fn := range queries {
 			t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
 				q := fn(db)
@@ -918,7 +1758,244 @@ func TestQuery(t *testing.T) {
 					query = timeRE.ReplaceAll(query, []byte("[TIME]"))
 					cupaloy.SnapshotT(t, string(query))
 				}
+
+				if _, ok := q.(*bun.SelectQuery); !ok {
+					return
+				}
+
+				// Select queries must be the same on a cache miss and on a cache hit.
+				for j := 0; j < 2; j++ {
+					conn := new(queryRecorder)
+					_, cachedErr := fn(cachedDB).(*bun.SelectQuery).Conn(conn).Exec(ctx)
+					if err != nil {
+						require.EqualError(t, cachedErr, err.Error())
+						continue
+					}
+					require.Equal(t, errQueryRecorded, cachedErr)
+					cached := timeRE.ReplaceAllString(conn.query, "[TIME]")
+					require.Equal(t, string(query), cached)
+				}
+			})
+		}
+	})
+}
+
+func TestQueryCacheShape(t *testing.T) {
+	type Model struct {
+		ID  int64 `bun:",pk,autoincrement"`
+		Str string
+	}
+
+	base := func(q *bun.SelectQuery) *bun.SelectQuery {
+		return q
+	}
+
+	// Each option changes one part of the base query that appendShape must add
+	// to the shape, so the option doesn't reuse the template of the base query.
+	options := []func(q *bun.SelectQuery) *bun.SelectQuery{
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.Only() },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.Distinct() },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.DistinctOn("str") },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.Table("other") },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.TableExpr("other AS o") },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.ModelTableExpr("other AS model") },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.Schema("other") },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.Column("str") },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.ColumnExpr("1") },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.ExcludeColumn("str") },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.Where("id = ?", 1) },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.Where("? = 1", bun.Ident("id")) },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.Where("? = 1", bun.Safe("id")) },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.WhereOr("id = 1") },
+		func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+				return q.Where("id = 1").WhereOr("id = 2")
+			})
+		},
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.WhereAllWithDeleted() },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.Group("str") },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.Group("str").Having("count(*) > 1") },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.Order("str") },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.OrderExpr("str DESC") },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.Limit(1) },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.LimitAll() },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.Offset(1) },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.IntoTable("other") },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.IntoTempTable("other") },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.TableSample("system", 10) },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.For("UPDATE") },
+		func(q *bun.SelectQuery) *bun.SelectQuery { return q.WithTotalCount() },
+		func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Join("JOIN other AS o").JoinOn("o.id = model.id")
+		},
+		func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Join("JOIN other AS o").JoinOn("o.id = model.id").JoinOnOr("o.str = model.str")
+		},
+	}
+
+	testEachDB(t, func(t *testing.T, dbName string, db *bun.DB) {
+		for i, option := range options {
+			t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+				cachedDB := db.WithQueryCache(1000)
+
+				for _, apply := range []func(*bun.SelectQuery) *bun.SelectQuery{base, option, base} {
+					query, err := apply(db.NewSelect().Model((*Model)(nil))).
+						AppendQuery(db.Formatter(), nil)
+
+					conn := new(queryRecorder)
+					_, cachedErr := apply(cachedDB.NewSelect().Model((*Model)(nil))).
+						Conn(conn).
+						Exec(ctx)
+					if err != nil {
+						require.EqualError(t, cachedErr, err.Error())
+						continue
+					}
+					require.Equal(t, errQueryRecorded, cachedErr)
+					require.Equal(t, string(query), conn.query)
+				}
 			})
 		}
 	})
 }
+
+var errQueryRecorded = errors.New("query recorded")
+
+// queryRecorder is a connection that records the query instead of executing it.
+type queryRecorder struct {
+	query string
+}
+
+var _ bun.IConn = (*queryRecorder)(nil)
+
+func (c *queryRecorder) QueryContext(
+	ctx context.Context, query string, args ...interface{},
+) (*sql.Rows, error) {
+	c.query = query
+	return nil, errQueryRecorded
+}
+
+func (c *queryRecorder) ExecContext(
+	ctx context.Context, query string, args ...interface{},
+) (sql.Result, error) {
+	c.query = query
+	return nil, errQueryRecorded
+}
+
+func (c *queryRecorder) QueryRowContext(
+	ctx context.Context, query string, args ...interface{},
+) *sql.Row {
+	panic("not implemented")
+}
+
+type customDialect struct {
+	schema.BaseDialect
+
+	tables *schema.Tables
+}
+
+var _ schema.Dialect = (*customDialect)(nil)
+
+func newCustomDialect() *customDialect {
+	d := new(customDialect)
+	d.tables = schema.NewTables(d)
+	return d
+}
+
+func (d *customDialect) Init(*sql.DB) {}
+
+func (d *customDialect) Name() dialect.Name {
+	return dialect.Invalid
+}
+
+func (d *customDialect) Features() feature.Feature {
+	return feature.Returning | feature.InsertReturning | feature.InValues |
+		feature.TableInheritance | feature.TableSample | feature.LimitAll |
+		feature.RowValues
+}
+
+func (d *customDialect) Tables() *schema.Tables {
+	return d.tables
+}
+
+func (d *customDialect) OnTable(table *schema.Table) {}
+
+func (d *customDialect) IdentQuote() byte {
+	return '`'
+}
+
+func (d *customDialect) AppendPlaceholder(b []byte, n int) []byte {
+	b = append(b, ':')
+	return strconv.AppendInt(b, int64(n), 10)
+}
+
+func (d *customDialect) AppendNoLimit(b []byte) []byte {
+	return append(b, " LIMIT -1"...)
+}
+
+func TestCustomDialect(t *testing.T) {
+	type Model struct {
+		ID  int64 `bun:",pk,autoincrement"`
+		Str string
+	}
+
+	db := bun.NewDB(nil, newCustomDialect())
+
+	query, err := db.NewCreateIndex().
+		Model((*Model)(nil)).
+		Unique().
+		Index("models_str_idx").
+		Column("str").
+		AppendQuery(db.Formatter(), nil)
+	require.NoError(t, err)
+	require.Equal(t, "CREATE UNIQUE INDEX `models_str_idx` ON `models` (`str`)", string(query))
+
+	query, err = db.NewInsert().Model(&Model{Str: "hello"}).AppendQuery(db.Formatter(), nil)
+	require.NoError(t, err)
+	require.Equal(t,
+		"INSERT INTO `models` (`str`) VALUES ('hello') RETURNING `id`", string(query))
+
+	query, args, err := db.NewSelect().
+		Model((*Model)(nil)).
+		Where("str = ?", "hello").
+		WhereInValuesJoin("id", []int64{1, 2}).
+		AppendQueryArgs(db.Formatter(), nil)
+	require.NoError(t, err)
+	require.Equal(t,
+		"SELECT `model`.`id`, `model`.`str` FROM `models` AS `model` "+
+			"WHERE (str = :1) AND (`id` IN (VALUES (1), (2)))", string(query))
+	require.Equal(t, []interface{}{"hello"}, args)
+
+	query, err = db.NewSelect().
+		Model((*Model)(nil)).
+		Only().
+		TableSample("system", 10).
+		LimitAll().
+		AppendQuery(db.Formatter(), nil)
+	require.NoError(t, err)
+	require.Equal(t,
+		"SELECT `model`.`id`, `model`.`str` FROM ONLY `models` AS `model` "+
+			"TABLESAMPLE SYSTEM (10) LIMIT ALL", string(query))
+
+	query, err = db.NewSelect().
+		Model((*Model)(nil)).
+		WhereTupleGreater([]string{"id", "str"}, []interface{}{1, "hello"}).
+		Offset(10).
+		AppendQuery(db.Formatter(), nil)
+	require.NoError(t, err)
+	require.Equal(t,
+		"SELECT `model`.`id`, `model`.`str` FROM `models` AS `model` "+
+			"WHERE ((`id`, `str`) > (1, 'hello')) LIMIT -1 OFFSET 10", string(query))
+
+	_, err = db.NewUpdate().
+		Model(&Model{ID: 1}).
+		SetJSON("str", "a.b", 1).
+		WherePK().
+		AppendQuery(db.Formatter(), nil)
+	require.EqualError(t, err, "bun: invalid does not support SetJSON")
+
+	_, err = db.NewSelect().
+		Model((*Model)(nil)).
+		OrderTrigramSimilarity("str", "hello").
+		AppendQuery(db.Formatter(), nil)
+	require.EqualError(t, err, "bun: invalid does not support pg_trgm")
+}
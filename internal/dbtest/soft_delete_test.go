@@ -21,6 +21,7 @@ func TestSoftDelete(t *testing.T) {
 		{run: testSoftDeleteAPI},
 		{run: testSoftDeleteBulk},
 		{run: testSoftDeleteForce},
+		{run: testSoftDeleteWhereOr},
 	}
 	testEachDB(t, func(t *testing.T, dbName string, db *bun.DB) {
 		for _, test := range tests {
@@ -50,6 +51,35 @@ func testSoftDeleteNilModel(t *testing.T, db *bun.DB) {
 	require.NoError(t, err)
 }
 
+func testSoftDeleteWhereOr(t *testing.T, db *bun.DB) {
+	ctx := context.Background()
+
+	err := db.ResetModel(ctx, (*Video)(nil))
+	require.NoError(t, err)
+
+	videos := []Video{
+		{Name: "foo1"},
+		{Name: "foo2"},
+		{Name: "bar1"},
+	}
+	_, err = db.NewInsert().Model(&videos).Exec(ctx)
+	require.NoError(t, err)
+
+	_, err = db.NewDelete().Model((*Video)(nil)).Where("name = ?", "foo2").Exec(ctx)
+	require.NoError(t, err)
+
+	var names []string
+	err = db.NewSelect().
+		Model((*Video)(nil)).
+		Column("name").
+		Where("name LIKE ?", "bar%").
+		WhereOr("name LIKE ?", "foo%").
+		Order("name").
+		Scan(ctx, &names)
+	require.NoError(t, err)
+	require.Equal(t, []string{"bar1", "foo1"}, names)
+}
+
 func testSoftDeleteAPI(t *testing.T, db *bun.DB) {
 	ctx := context.Background()
 
@@ -13,7 +13,14 @@ import (
 
 var errNilModel = errors.New("bun: Model(nil)")
 
-var timeType = reflect.TypeOf((*time.Time)(nil)).Elem()
+var (
+	bytesType = reflect.TypeOf((*[]byte)(nil)).Elem()
+	intType   = reflect.TypeOf((*int)(nil)).Elem()
+	timeType  = reflect.TypeOf((*time.Time)(nil)).Elem()
+)
+
+// totalCountColumn is the name of the column added by SelectQuery.WithTotalCount.
+const totalCountColumn = "__total"
 
 type Model = schema.Model
 
@@ -50,22 +57,26 @@ func newModel(db *DB, dest []interface{}) (Model, error) {
 	}
 
 	values := make([]reflect.Value, len(dest))
+	var numSlices int
 
 	for i, el := range dest {
 		v := reflect.ValueOf(el)
 		if v.Kind() != reflect.Ptr {
-			return nil, fmt.Errorf("bun: Scan(non-pointer %T)", dest)
+			return nil, fmt.Errorf("bun: Scan(non-pointer %T)", el)
 		}
 
 		v = v.Elem()
-		if v.Kind() != reflect.Slice {
-			return newScanModel(db, dest), nil
+		if v.Kind() == reflect.Slice && v.Type() != bytesType {
+			numSlices++
 		}
-
 		values[i] = v
 	}
 
-	return newSliceModel(db, dest, values), nil
+	if numSlices == len(dest) {
+		return newSliceModel(db, dest, values), nil
+	}
+	// Columns are scanned positionally, one column per destination.
+	return newScanModel(db, dest), nil
 }
 
 func newSingleModel(db *DB, dest interface{}) (Model, error) {
@@ -89,6 +100,9 @@ func _newModel(db *DB, dest interface{}, scan bool) (Model, error) {
 	if !v.IsValid() {
 		return nil, errNilModel
 	}
+	if v.Kind() == reflect.Chan && scan {
+		return newChanModel(db, v)
+	}
 	if v.Kind() != reflect.Ptr {
 		return nil, fmt.Errorf("bun: Model(non-pointer %T)", dest)
 	}
@@ -199,3 +213,15 @@ func isSingleRowModel(m Model) bool {
 		return false
 	}
 }
+
+func setTotalCountDest(model Model, dest *int) error {
+	switch model := model.(type) {
+	case *structTableModel:
+		model.totalCount = dest
+	case *sliceTableModel:
+		model.totalCount = dest
+	default:
+		return fmt.Errorf("bun: got %T, but WithTotalCount requires a struct or slice-based model", model)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package bun
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+// chanModel streams scanned rows into a channel one row at a time.
+type chanModel struct {
+	db *DB
+
+	ch       reflect.Value
+	elemType reflect.Type
+	isPtr    bool
+	isStruct bool
+
+	closed bool
+}
+
+var _ Model = (*chanModel)(nil)
+
+func newChanModel(db *DB, v reflect.Value) (*chanModel, error) {
+	typ := v.Type()
+	if typ.ChanDir()&reflect.SendDir == 0 {
+		return nil, fmt.Errorf("bun: Scan(receive-only %s)", typ)
+	}
+	if v.IsNil() {
+		return nil, fmt.Errorf("bun: Scan(nil %s)", typ)
+	}
+
+	m := &chanModel{
+		db:       db,
+		ch:       v,
+		elemType: typ.Elem(),
+	}
+	if m.elemType.Kind() == reflect.Ptr {
+		m.elemType = m.elemType.Elem()
+		m.isPtr = true
+	}
+	m.isStruct = m.elemType.Kind() == reflect.Struct && m.elemType != timeType
+	return m, nil
+}
+
+func (m *chanModel) Value() interface{} {
+	return m.ch.Interface()
+}
+
+func (m *chanModel) ScanRows(ctx context.Context, rows *sql.Rows) (int, error) {
+	defer m.close()
+
+	var n int
+	for rows.Next() {
+		elem := reflect.New(m.elemType)
+
+		var model rowScanner
+		if m.isStruct {
+			model = newStructTableModelValue(m.db, elem.Interface(), elem.Elem())
+		} else {
+			model = newScanModel(m.db, []interface{}{elem.Interface()})
+		}
+
+		if err := model.ScanRow(ctx, rows); err != nil {
+			return 0, err
+		}
+
+		if !m.isPtr {
+			elem = elem.Elem()
+		}
+		if err := m.send(ctx, elem); err != nil {
+			return 0, err
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+func (m *chanModel) send(ctx context.Context, elem reflect.Value) error {
+	chosen, _, _ := reflect.Select([]reflect.SelectCase{
+		{Dir: reflect.SelectSend, Chan: m.ch, Send: elem},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+	})
+	if chosen == 1 {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// close closes the channel so readers can range over it. It is safe to call
+// close more than once.
+func (m *chanModel) close() {
+	if !m.closed {
+		m.closed = true
+		m.ch.Close()
+	}
+}
+
+// closeChanModel closes the channel of the model when the query fails
+// before any rows are scanned.
+func closeChanModel(model Model) {
+	if m, ok := model.(*chanModel); ok {
+		m.close()
+	}
+}
+
+// closeChanDest closes the channels in dest when the query fails before
+// the model is created. Nil and receive-only channels can't be closed.
+func closeChanDest(dest []interface{}) {
+	var closed []uintptr
+	for _, el := range dest {
+		v := reflect.ValueOf(el)
+		if v.Kind() != reflect.Chan || v.IsNil() || v.Type().ChanDir()&reflect.SendDir == 0 {
+			continue
+		}
+		if containsPointer(closed, v.Pointer()) {
+			continue
+		}
+		closed = append(closed, v.Pointer())
+		v.Close()
+	}
+}
+
+func containsPointer(ptrs []uintptr, ptr uintptr) bool {
+	for _, p := range ptrs {
+		if p == ptr {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package bun
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+
+	"github.com/uptrace/bun/internal"
+	"github.com/uptrace/bun/schema"
+)
+
+// columnModel scans a single column into a slice and discards other columns.
+type columnModel struct {
+	dest   interface{}
+	slice  reflect.Value
+	column interface{}
+
+	index     int
+	scanIndex int
+	nextElem  func() reflect.Value
+	scan      schema.ScannerFunc
+}
+
+var _ Model = (*columnModel)(nil)
+
+func newColumnModel(column, dest interface{}) (*columnModel, error) {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return nil, fmt.Errorf("bun: ScanColumn(non-pointer-to-slice %T)", dest)
+	}
+
+	switch column.(type) {
+	case int, string:
+	default:
+		return nil, fmt.Errorf("bun: ScanColumn(unsupported column %T)", column)
+	}
+
+	return &columnModel{
+		dest:   dest,
+		slice:  v.Elem(),
+		column: column,
+	}, nil
+}
+
+func (m *columnModel) Value() interface{} {
+	return m.dest
+}
+
+func (m *columnModel) ScanRows(ctx context.Context, rows *sql.Rows) (int, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	m.index, err = m.columnIndex(columns)
+	if err != nil {
+		return 0, err
+	}
+
+	if m.slice.Len() > 0 {
+		m.slice.Set(m.slice.Slice(0, 0))
+	}
+	m.nextElem = internal.MakeSliceNextElemFunc(m.slice)
+	m.scan = schema.Scanner(m.slice.Type().Elem())
+
+	dest := makeDest(m, len(columns))
+
+	var n int
+
+	for rows.Next() {
+		m.scanIndex = 0
+		if err := rows.Scan(dest...); err != nil {
+			return 0, err
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+func (m *columnModel) columnIndex(columns []string) (int, error) {
+	switch column := m.column.(type) {
+	case int:
+		if column < 0 || column >= len(columns) {
+			return 0, fmt.Errorf(
+				"bun: ScanColumn got column index %d, but the query has %d columns",
+				column, len(columns))
+		}
+		return column, nil
+	default:
+		for i, col := range columns {
+			if col == column {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("bun: ScanColumn got unknown column %q", column)
+	}
+}
+
+func (m *columnModel) Scan(src interface{}) error {
+	index := m.scanIndex
+	m.scanIndex++
+
+	if index != m.index {
+		return nil
+	}
+	return m.scan(m.nextElem(), src)
+}
@@ -3,6 +3,7 @@ package bun
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"reflect"
 
 	"github.com/uptrace/bun/schema"
@@ -33,6 +34,15 @@ func (m *scanModel) ScanRows(ctx context.Context, rows *sql.Rows) (int, error) {
 		return 0, rows.Err()
 	}
 
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	if len(columns) != len(m.dest) {
+		return 0, fmt.Errorf(
+			"bun: got %d columns, but Scan has %d destinations", len(columns), len(m.dest))
+	}
+
 	dest := makeDest(m, len(m.dest))
 
 	m.scanIndex = 0
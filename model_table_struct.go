@@ -28,6 +28,9 @@ type structTableModel struct {
 
 	columns   []string
 	scanIndex int
+
+	// totalCount receives the window column added by SelectQuery.WithTotalCount.
+	totalCount *int
 }
 
 var _ TableModel = (*structTableModel)(nil)
@@ -301,13 +304,21 @@ func (m *structTableModel) scanRow(ctx context.Context, rows *sql.Rows, dest []i
 }
 
 func (m *structTableModel) Scan(src interface{}) error {
-	column := m.columns[m.scanIndex]
+	column := unquote(m.columns[m.scanIndex])
 	m.scanIndex++
 
-	return m.ScanColumn(unquote(column), src)
+	err := m.ScanColumn(column, src)
+	if err, ok := err.(*scanColumnError); ok {
+		// Report the full column name for columns of joined models.
+		err.column = column
+	}
+	return err
 }
 
 func (m *structTableModel) ScanColumn(column string, src interface{}) error {
+	if m.totalCount != nil && column == totalCountColumn {
+		return schema.Scanner(intType)(reflect.ValueOf(m.totalCount).Elem(), src)
+	}
 	if ok, err := m.scanColumn(column, src); ok {
 		return err
 	}
@@ -328,7 +339,10 @@ func (m *structTableModel) scanColumn(column string, src interface{}) (bool, err
 		if src == nil && m.isNil() {
 			return true, nil
 		}
-		return true, field.ScanValue(m.strct, src)
+		if err := field.ScanValue(m.strct, src); err != nil {
+			return true, &scanColumnError{column: column, typ: field.IndirectType, err: err}
+		}
+		return true, nil
 	}
 
 	if joinName, column := splitColumn(column); joinName != "" {
@@ -371,3 +385,19 @@ func splitColumn(s string) (string, string) {
 	}
 	return "", s
 }
+
+//------------------------------------------------------------------------------
+
+type scanColumnError struct {
+	column string
+	typ    reflect.Type
+	err    error
+}
+
+func (e *scanColumnError) Error() string {
+	return fmt.Sprintf("bun: scan column %q into %s: %s", e.column, e.typ, e.err)
+}
+
+func (e *scanColumnError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,85 @@
+package bun
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/uptrace/bun/dialect/feature"
+	"github.com/uptrace/bun/schema"
+)
+
+// AggExpr builds an aggregate expression with an optional FILTER clause
+// that can be passed to ColumnExpr:
+//
+//    q.ColumnExpr("?", bun.Agg("count(*)").
+//        Filter("status = ?", "active").
+//        As("active_count"))
+//
+// FILTER is supported by PostgreSQL and SQLite.
+type AggExpr struct {
+	agg    schema.QueryWithArgs
+	filter schema.QueryWithArgs
+	alias  string
+}
+
+var (
+	_ schema.QueryAppender = (*AggExpr)(nil)
+	_ dialectChecker       = (*AggExpr)(nil)
+)
+
+func Agg(query string, args ...interface{}) *AggExpr {
+	return &AggExpr{
+		agg: schema.SafeQuery(query, args),
+	}
+}
+
+func (a *AggExpr) Filter(query string, args ...interface{}) *AggExpr {
+	a.filter = schema.SafeQuery(query, args)
+	return a
+}
+
+func (a *AggExpr) As(alias string) *AggExpr {
+	a.alias = alias
+	return a
+}
+
+func (a *AggExpr) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte, err error) {
+	if a.agg.Query == "" {
+		return nil, errors.New("bun: Agg requires an aggregate expression")
+	}
+
+	b, err = a.agg.AppendQuery(fmter, b)
+	if err != nil {
+		return nil, err
+	}
+
+	if !a.filter.IsZero() {
+		if err := a.checkDialect(fmter.Dialect()); err != nil {
+			return nil, err
+		}
+
+		b = append(b, " FILTER (WHERE "...)
+		b, err = a.filter.AppendQuery(fmter, b)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, ')')
+	}
+
+	if a.alias != "" {
+		b = append(b, " AS "...)
+		b = fmter.AppendIdent(b, a.alias)
+	}
+
+	return b, nil
+}
+
+func (a *AggExpr) checkDialect(d schema.Dialect) error {
+	if a.filter.IsZero() {
+		return nil
+	}
+	if !d.Features().Has(feature.AggFilter) {
+		return fmt.Errorf("bun: %s does not support FILTER, use CASE instead", d.Name())
+	}
+	return nil
+}
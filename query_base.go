@@ -1,11 +1,16 @@
 package bun
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"database/sql/driver"
 	"errors"
 	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/uptrace/bun/dialect/feature"
@@ -17,6 +22,9 @@ const (
 	forceDeleteFlag internal.Flag = 1 << iota
 	deletedFlag
 	allWithDeletedFlag
+	wherePKSortedFlag
+	onlyFlag
+	useReplicaFlag
 )
 
 type withQuery struct {
@@ -51,12 +59,16 @@ type IDB interface {
 	NewUpdate() *UpdateQuery
 	NewDelete() *DeleteQuery
 	NewCreateTable() *CreateTableQuery
+	NewCreateTableAs(name string, query schema.QueryAppender) *CreateTableAsQuery
 	NewDropTable() *DropTableQuery
 	NewCreateIndex() *CreateIndexQuery
 	NewDropIndex() *DropIndexQuery
+	NewRenameIndex() *RenameIndexQuery
 	NewTruncateTable() *TruncateTableQuery
 	NewAddColumn() *AddColumnQuery
 	NewDropColumn() *DropColumnQuery
+	NewAlterColumn() *AlterColumnQuery
+	NewAddUniqueConstraint() *AddUniqueConstraintQuery
 
 	BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error)
 	RunInTx(ctx context.Context, opts *sql.TxOptions, f func(ctx context.Context, tx Tx) error) error
@@ -100,6 +112,7 @@ type baseQuery struct {
 	modelTableName schema.QueryWithArgs
 	tables         []schema.QueryWithArgs
 	columns        []schema.QueryWithArgs
+	schemaName     string
 
 	flags internal.Flag
 }
@@ -329,14 +342,134 @@ func (q *baseQuery) appendSelectFromValues(
 
 //------------------------------------------------------------------------------
 
+// dialectChecker is implemented by expressions such as Func, Agg, and Case
+// that only some dialects support or that can be incomplete, so the query
+// fails when they are added instead of when they are formatted.
+type dialectChecker interface {
+	checkDialect(d schema.Dialect) error
+}
+
+// checkArgsDialect sets the error on the query when an argument is not
+// supported by the dialect, instead of formatting the error into the SQL.
+func (q *baseQuery) checkArgsDialect(query schema.QueryWithArgs) bool {
+	for _, arg := range query.Args {
+		if c, ok := arg.(dialectChecker); ok {
+			if err := c.checkDialect(q.db.Dialect()); err != nil {
+				q.setErr(err)
+				return false
+			}
+		}
+	}
+	return true
+}
+
 func (q *baseQuery) addTable(table schema.QueryWithArgs) {
+	if !q.checkArgsDialect(table) {
+		return
+	}
 	q.tables = append(q.tables, table)
 }
 
 func (q *baseQuery) addColumn(column schema.QueryWithArgs) {
+	if !q.checkSafeQuery(column) || !q.checkArgsDialect(column) {
+		return
+	}
 	q.columns = append(q.columns, column)
 }
 
+// checkSafeQuery rejects SafeQuery-backed expressions that contain a ';'
+// outside of string literals and quoted identifiers so a raw expression
+// can't be used to append another statement. With WithDebugChecks,
+// it also rejects expressions with unbalanced parentheses.
+func (q *baseQuery) checkSafeQuery(query schema.QueryWithArgs) bool {
+	if query.Args == nil { // identifiers are always quoted
+		return true
+	}
+	if hasStatementSeparator(query.Query) {
+		q.setErr(fmt.Errorf("bun: query %q contains multiple statements", query.Query))
+		return false
+	}
+	if q.db != nil && q.db.flags.Has(debugChecksFlag) && !hasBalancedParens(query.Query) {
+		q.setErr(fmt.Errorf("bun: query %q has unbalanced parentheses", query.Query))
+		return false
+	}
+	return true
+}
+
+func hasBalancedParens(s string) bool {
+	var depth int
+	for i := 0; i < len(s); i++ {
+		if end := skipQuoted(s, i); end != i {
+			i = end - 1
+			continue
+		}
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+func hasStatementSeparator(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if end := skipQuoted(s, i); end != i {
+			i = end - 1
+			continue
+		}
+		if s[i] == ';' {
+			return true
+		}
+	}
+	return false
+}
+
+// skipQuoted returns the index after the string literal, quoted identifier,
+// or PostgreSQL dollar-quoted string that starts at s[i], or i if there is none.
+// Backslashes escape quotes only in PostgreSQL E'...' strings.
+func skipQuoted(s string, i int) int {
+	switch c := s[i]; c {
+	case '\'':
+		escapes := i > 0 && (s[i-1] == 'E' || s[i-1] == 'e') && (i == 1 || !isIdentByte(s[i-2]))
+		for j := i + 1; j < len(s); j++ {
+			switch {
+			case escapes && s[j] == '\\':
+				j++
+			case s[j] == '\'':
+				return j + 1
+			}
+		}
+		return len(s)
+	case '"', '`':
+		if j := strings.IndexByte(s[i+1:], c); j != -1 {
+			return i + 1 + j + 1
+		}
+		return len(s)
+	case '$':
+		if i > 0 && isIdentByte(s[i-1]) {
+			return i
+		}
+		j := i + 1
+		for j < len(s) && s[j] != '$' && isIdentByte(s[j]) {
+			j++
+		}
+		if j == len(s) || s[j] != '$' || (j > i+1 && s[i+1] >= '0' && s[i+1] <= '9') {
+			return i
+		}
+		tag := s[i : j+1]
+		if k := strings.Index(s[j+1:], tag); k != -1 {
+			return j + 1 + k + len(tag)
+		}
+		return len(s)
+	}
+	return i
+}
+
 func (q *baseQuery) excludeColumn(columns []string) {
 	if q.table == nil {
 		q.setErr(errNilModel)
@@ -355,10 +488,18 @@ func (q *baseQuery) excludeColumn(columns []string) {
 	}
 
 	for _, column := range columns {
-		if !q._excludeColumn(column) {
-			q.setErr(fmt.Errorf("bun: can't find column=%q", column))
+		if q._excludeColumn(column) {
+			continue
+		}
+		if q.table.HasField(column) {
+			// Columns were already selected with Column, so ExcludeColumn
+			// can only remove columns from that selection.
+			q.setErr(fmt.Errorf(
+				"bun: can't exclude column=%q: it is not among the selected columns", column))
 			return
 		}
+		q.setErr(fmt.Errorf("bun: can't find column=%q", column))
+		return
 	}
 }
 
@@ -400,6 +541,7 @@ func (q *baseQuery) appendTablesWithAlias(
 func (q *baseQuery) _appendTables(
 	fmter schema.Formatter, b []byte, withAlias bool,
 ) (_ []byte, err error) {
+	b = q.appendOnly(fmter, b)
 	startLen := len(b)
 
 	if q.modelHasTableName() {
@@ -409,8 +551,8 @@ func (q *baseQuery) _appendTables(
 				return nil, err
 			}
 		} else {
-			b = fmter.AppendQuery(b, string(q.table.SQLNameForSelects))
-			if withAlias && q.table.SQLAlias != q.table.SQLNameForSelects {
+			b = q.appendTableName(fmter, b, q.table, q.table.SQLNameForSelects)
+			if withAlias && (q.db.tablePrefix != "" || q.table.SQLAlias != q.table.SQLNameForSelects) {
 				b = append(b, " AS "...)
 				b = append(b, q.table.SQLAlias...)
 			}
@@ -421,7 +563,7 @@ func (q *baseQuery) _appendTables(
 		if len(b) > startLen {
 			b = append(b, ", "...)
 		}
-		b, err = table.AppendQuery(fmter, b)
+		b, err = q.appendTable(fmter, b, table)
 		if err != nil {
 			return nil, err
 		}
@@ -443,12 +585,14 @@ func (q *baseQuery) appendFirstTableWithAlias(
 func (q *baseQuery) _appendFirstTable(
 	fmter schema.Formatter, b []byte, withAlias bool,
 ) ([]byte, error) {
+	b = q.appendOnly(fmter, b)
+
 	if !q.modelTableName.IsZero() {
 		return q.modelTableName.AppendQuery(fmter, b)
 	}
 
 	if q.table != nil {
-		b = fmter.AppendQuery(b, string(q.table.SQLName))
+		b = q.appendTableName(fmter, b, q.table, q.table.SQLName)
 		if withAlias {
 			b = append(b, " AS "...)
 			b = append(b, q.table.SQLAlias...)
@@ -457,12 +601,75 @@ func (q *baseQuery) _appendFirstTable(
 	}
 
 	if len(q.tables) > 0 {
-		return q.tables[0].AppendQuery(fmter, b)
+		return q.appendTable(fmter, b, q.tables[0])
 	}
 
 	return nil, errors.New("bun: query does not have a table")
 }
 
+func (q *baseQuery) getSchema() string {
+	if q.schemaName != "" {
+		return q.schemaName
+	}
+	return q.db.schemaName
+}
+
+// appendSchema qualifies the table with the schema set by Schema or DB.WithSchema
+// unless the table name is already qualified.
+func (q *baseQuery) appendSchema(fmter schema.Formatter, b []byte, table string) []byte {
+	name := q.getSchema()
+	if name == "" || strings.IndexByte(table, '.') >= 0 {
+		return b
+	}
+	b = fmter.AppendIdent(b, name)
+	return append(b, '.')
+}
+
+// appendTable appends a table added with Table qualified with the schema and
+// the prefix. Tables added with TableExpr and tables that reference a CTE
+// are appended as is.
+func (q *baseQuery) appendTable(
+	fmter schema.Formatter, b []byte, table schema.QueryWithArgs,
+) ([]byte, error) {
+	if table.Args != nil || q.isWithName(table.Query) {
+		return table.AppendQuery(fmter, b)
+	}
+	b = q.appendSchema(fmter, b, table.Query)
+	return fmter.AppendIdent(b, q.db.addTablePrefix(table.Query)), nil
+}
+
+func (q *baseQuery) isWithName(name string) bool {
+	for _, with := range q.with {
+		if with.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// appendTableName appends the model table name qualified with the schema and
+// with the prefix set by DB.WithTablePrefix. It is used for the query table as
+// well as for joined tables and foreign key references, so all of them use
+// the same schema. Custom names set with the select tag are not prefixed.
+func (q *baseQuery) appendTableName(
+	fmter schema.Formatter, b []byte, table *schema.Table, name schema.Safe,
+) []byte {
+	b = q.appendSchema(fmter, b, table.Name)
+	if q.db.tablePrefix == "" || name != table.SQLName {
+		return fmter.AppendQuery(b, string(name))
+	}
+	return fmter.AppendIdent(b, q.db.addTablePrefix(table.Name))
+}
+
+// appendOnly appends the ONLY keyword that excludes PostgreSQL child tables.
+// Other dialects don't support table inheritance so the keyword is omitted.
+func (q *baseQuery) appendOnly(fmter schema.Formatter, b []byte) []byte {
+	if q.flags.Has(onlyFlag) && fmter.HasFeature(feature.TableInheritance) {
+		b = append(b, "ONLY "...)
+	}
+	return b
+}
+
 func (q *baseQuery) hasMultiTables() bool {
 	if q.modelHasTableName() {
 		return len(q.tables) >= 1
@@ -479,7 +686,7 @@ func (q *baseQuery) appendOtherTables(fmter schema.Formatter, b []byte) (_ []byt
 		if i > 0 {
 			b = append(b, ", "...)
 		}
-		b, err = table.AppendQuery(fmter, b)
+		b, err = q.appendTable(fmter, b, table)
 		if err != nil {
 			return nil, err
 		}
@@ -543,6 +750,21 @@ func (q *baseQuery) _getFields(omitPK bool) ([]*schema.Field, error) {
 	return fields, nil
 }
 
+// queryConn returns the connection used to run read queries, which is
+// a read replica for queries with UseReplica that are not bound to
+// a connection or a transaction.
+func (q *baseQuery) queryConn() IConn {
+	if !q.flags.Has(useReplicaFlag) {
+		return q.conn
+	}
+	if db, ok := q.conn.(*sql.DB); ok && db == q.db.DB {
+		if conn := q.db.replicas.conn(); conn != nil {
+			return conn
+		}
+	}
+	return q.conn
+}
+
 func (q *baseQuery) scan(
 	ctx context.Context,
 	iquery Query,
@@ -552,7 +774,7 @@ func (q *baseQuery) scan(
 ) (sql.Result, error) {
 	ctx, event := q.db.beforeQuery(ctx, iquery, query, nil, query, q.model)
 
-	rows, err := q.conn.QueryContext(ctx, query)
+	rows, err := q.queryConn().QueryContext(ctx, query)
 	if err != nil {
 		q.db.afterQuery(ctx, event, nil, err)
 		return nil, err
@@ -601,7 +823,7 @@ func (q *baseQuery) AppendNamedArg(fmter schema.Formatter, b []byte, name string
 
 	switch name {
 	case "TableName":
-		b = fmter.AppendQuery(b, string(q.table.SQLName))
+		b = q.appendTableName(fmter, b, q.table, q.table.SQLName)
 		return b, true
 	case "TableAlias":
 		b = fmter.AppendQuery(b, string(q.table.SQLAlias))
@@ -653,6 +875,10 @@ func (q *baseQuery) NewCreateTable() *CreateTableQuery {
 	return NewCreateTableQuery(q.db).Conn(q.conn)
 }
 
+func (q *baseQuery) NewCreateTableAs(name string, query schema.QueryAppender) *CreateTableAsQuery {
+	return NewCreateTableAsQuery(q.db).Conn(q.conn).Table(name).As(query)
+}
+
 func (q *baseQuery) NewDropTable() *DropTableQuery {
 	return NewDropTableQuery(q.db).Conn(q.conn)
 }
@@ -665,6 +891,10 @@ func (q *baseQuery) NewDropIndex() *DropIndexQuery {
 	return NewDropIndexQuery(q.db).Conn(q.conn)
 }
 
+func (q *baseQuery) NewRenameIndex() *RenameIndexQuery {
+	return NewRenameIndexQuery(q.db).Conn(q.conn)
+}
+
 func (q *baseQuery) NewTruncateTable() *TruncateTableQuery {
 	return NewTruncateTableQuery(q.db).Conn(q.conn)
 }
@@ -677,6 +907,14 @@ func (q *baseQuery) NewDropColumn() *DropColumnQuery {
 	return NewDropColumnQuery(q.db).Conn(q.conn)
 }
 
+func (q *baseQuery) NewAlterColumn() *AlterColumnQuery {
+	return NewAlterColumnQuery(q.db).Conn(q.conn)
+}
+
+func (q *baseQuery) NewAddUniqueConstraint() *AddUniqueConstraintQuery {
+	return NewAddUniqueConstraintQuery(q.db).Conn(q.conn)
+}
+
 //------------------------------------------------------------------------------
 
 func appendColumns(b []byte, table schema.Safe, fields []*schema.Field) []byte {
@@ -694,11 +932,48 @@ func appendColumns(b []byte, table schema.Safe, fields []*schema.Field) []byte {
 	return b
 }
 
-func formatterWithModel(fmter schema.Formatter, model schema.NamedArgAppender) schema.Formatter {
+// appendIfNotExistsDoBlock wraps the statement in a PostgreSQL DO block
+// that ignores errors caused by objects that already exist.
+func appendIfNotExistsDoBlock(
+	fmter schema.Formatter,
+	b []byte,
+	appendQuery func(schema.Formatter, []byte) ([]byte, error),
+) (_ []byte, err error) {
+	if !fmter.HasFeature(feature.DoBlock) {
+		return nil, fmt.Errorf("bun: %s does not support DO blocks", fmter.Dialect().Name())
+	}
+
+	b = append(b, "DO $$ BEGIN "...)
+	b, err = appendQuery(fmter, b)
+	if err != nil {
+		return nil, err
+	}
+	b = append(b, "; EXCEPTION WHEN duplicate_object OR duplicate_table THEN null; END $$"...)
+	return b, nil
+}
+
+// appendQueryArgs formats the query with placeholders in place of query
+// arguments and returns the arguments in the order they appear.
+func appendQueryArgs(
+	fmter schema.Formatter, b []byte, q schema.QueryAppender,
+) (_ []byte, args []interface{}, err error) {
+	args = make([]interface{}, 0)
+	b, err = q.AppendQuery(fmter.WithBindArgs(&args), b)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, args, nil
+}
+
+// formatterWithModel returns a formatter that resolves named args using
+// the model and renders empty In lists using the DB EmptyInPolicy.
+func formatterWithModel(
+	fmter schema.Formatter, db *DB, model schema.NamedArgAppender,
+) schema.Formatter {
 	if fmter.IsNop() {
 		return fmter
 	}
-	return fmter.WithArg(model)
+	return fmter.WithArg(model).WithEmptyInPolicy(db.emptyInPolicy)
 }
 
 //------------------------------------------------------------------------------
@@ -711,6 +986,9 @@ type whereBaseQuery struct {
 }
 
 func (q *whereBaseQuery) addWhere(where schema.QueryWithSep) {
+	if !q.checkSafeQuery(where.QueryWithArgs) {
+		return
+	}
 	q.where = append(q.where, where)
 }
 
@@ -728,6 +1006,436 @@ func (q *whereBaseQuery) addWhereGroup(sep string, where []schema.QueryWithSep)
 	q.addWhere(schema.SafeQueryWithSep("", nil, ")"))
 }
 
+func (q *whereBaseQuery) conditions() []schema.QueryWithSep {
+	where := make([]schema.QueryWithSep, len(q.where))
+	copy(where, q.where)
+	return where
+}
+
+func (q *whereBaseQuery) setConditions(where []schema.QueryWithSep) {
+	q.where = nil
+	for _, w := range where {
+		q.addWhere(w)
+	}
+}
+
+func (q *whereBaseQuery) addWhereQuery(sep string, other *whereBaseQuery) {
+	// Copy the conditions because addWhereGroup resets the first separator.
+	where := make([]schema.QueryWithSep, len(other.where))
+	copy(where, other.where)
+	q.addWhereGroup(sep, where)
+}
+
+func (q *whereBaseQuery) addWhereSub(
+	column, op, quantifier string, query schema.QueryAppender,
+) {
+	if !q.hasFeature(feature.SubqueryQuantifier) {
+		q.setErr(fmt.Errorf("bun: %s does not support %s with a subquery", q.db.Dialect().Name(), quantifier))
+		return
+	}
+	if !q.checkComparisonOp(op) {
+		return
+	}
+	q.addWhere(schema.SafeQueryWithSep(
+		"? "+op+" "+quantifier+" (?)",
+		[]interface{}{schema.Ident(column), query},
+		" AND ",
+	))
+}
+
+func (q *whereBaseQuery) addWhereCollate(
+	column, op string, value interface{}, collation string,
+) {
+	if !q.checkComparisonOp(op) {
+		return
+	}
+	if !isCollationName(collation) {
+		q.setErr(fmt.Errorf("bun: invalid collation name %q", collation))
+		return
+	}
+	q.addWhere(schema.SafeQueryWithSep(
+		"? "+op+" ? COLLATE ?",
+		[]interface{}{schema.Ident(column), value, collationName(collation)},
+		" AND ",
+	))
+}
+
+func (q *whereBaseQuery) checkComparisonOp(op string) bool {
+	switch op {
+	case "=", "<>", "!=", "<", "<=", ">", ">=", "LIKE":
+		return true
+	default:
+		q.setErr(fmt.Errorf("bun: unsupported comparison operator %q", op))
+		return false
+	}
+}
+
+func isCollationName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '_', c == '-', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// collationName is quoted on PostgreSQL and SQLite, where collation names
+// are identifiers that may contain dashes, and appended as is elsewhere.
+type collationName string
+
+var _ schema.QueryAppender = collationName("")
+
+func (c collationName) AppendQuery(fmter schema.Formatter, b []byte) ([]byte, error) {
+	if fmter.HasFeature(feature.QuotedCollation) {
+		return fmter.AppendIdent(b, string(c)), nil
+	}
+	return append(b, c...), nil
+}
+
+func (q *whereBaseQuery) addWhereTuple(op string, columns []string, values []interface{}) {
+	if len(columns) == 0 || len(columns) != len(values) {
+		q.setErr(fmt.Errorf(
+			"bun: tuple comparison got %d columns and %d values", len(columns), len(values)))
+		return
+	}
+	// A comparison with NULL is never true, so the rows after a NULL cursor
+	// value would be skipped silently.
+	for i, v := range values {
+		if isNilValue(v) {
+			q.setErr(fmt.Errorf(
+				"bun: tuple comparison got NULL for column %q, use COALESCE or a non-nullable sort key",
+				columns[i]))
+			return
+		}
+	}
+	q.addWhere(schema.SafeQueryWithSep("?", []interface{}{&tupleComparison{
+		op:      op,
+		columns: columns,
+		values:  values,
+	}}, " AND "))
+}
+
+func isNilValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		if rv.IsNil() {
+			return true
+		}
+	}
+	if valuer, ok := v.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		return err == nil && value == nil
+	}
+	return false
+}
+
+// tupleComparison renders a row comparison such as (a, b) > (1, 2).
+// On dialects without row values, for example, MSSQL, the comparison
+// is expanded to (a > 1) OR (a = 1 AND b > 2).
+type tupleComparison struct {
+	op      string
+	columns []string
+	values  []interface{}
+}
+
+var _ schema.QueryAppender = (*tupleComparison)(nil)
+
+func (c *tupleComparison) AppendQuery(fmter schema.Formatter, b []byte) ([]byte, error) {
+	if !fmter.HasFeature(feature.RowValues) {
+		return c.appendExpanded(fmter, b), nil
+	}
+
+	b = append(b, '(')
+	for i, col := range c.columns {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		b = fmter.AppendIdent(b, col)
+	}
+	b = append(b, ") "...)
+	b = append(b, c.op...)
+	b = append(b, " ("...)
+	for i, v := range c.values {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		b = schema.Append(fmter, b, v)
+	}
+	b = append(b, ')')
+	return b, nil
+}
+
+func (c *tupleComparison) appendExpanded(fmter schema.Formatter, b []byte) []byte {
+	strictOp := strings.TrimSuffix(c.op, "=")
+	for i := range c.columns {
+		if i > 0 {
+			b = append(b, " OR "...)
+		}
+		b = append(b, '(')
+		for j := 0; j < i; j++ {
+			b = fmter.AppendIdent(b, c.columns[j])
+			b = append(b, " = "...)
+			b = schema.Append(fmter, b, c.values[j])
+			b = append(b, " AND "...)
+		}
+		b = fmter.AppendIdent(b, c.columns[i])
+		b = append(b, ' ')
+		if i == len(c.columns)-1 {
+			b = append(b, c.op...)
+		} else {
+			b = append(b, strictOp...)
+		}
+		b = append(b, ' ')
+		b = schema.Append(fmter, b, c.values[i])
+		b = append(b, ')')
+	}
+	return b
+}
+
+// maxWhereInFuncValues limits the number of values pulled by WhereInFunc.
+const maxWhereInFuncValues = 10000
+
+func (q *whereBaseQuery) addWhereInFunc(column string, next func() (interface{}, bool)) {
+	var values []interface{}
+	for {
+		v, ok := next()
+		if !ok {
+			break
+		}
+		if len(values) == maxWhereInFuncValues {
+			q.setErr(fmt.Errorf("bun: WhereInFunc got more than %d values", maxWhereInFuncValues))
+			return
+		}
+		values = append(values, v)
+	}
+
+	q.addWhere(schema.SafeQueryWithSep(
+		"? IN (?)",
+		[]interface{}{schema.Ident(column), In(values)},
+		" AND ",
+	))
+}
+
+func (q *whereBaseQuery) addWhereInValuesJoin(column string, values interface{}) {
+	slice := reflect.ValueOf(values)
+	if slice.Kind() != reflect.Slice {
+		q.setErr(fmt.Errorf("bun: WhereInValuesJoin(non-slice %T)", values))
+		return
+	}
+
+	// Empty lists are left to In so the DB EmptyInPolicy still applies.
+	var arg interface{} = In(values)
+	if slice.Len() > 0 {
+		arg = &valuesList{slice: slice, in: arg.(schema.QueryAppender)}
+	}
+
+	q.addWhere(schema.SafeQueryWithSep(
+		"? IN (?)",
+		[]interface{}{schema.Ident(column), arg},
+		" AND ",
+	))
+}
+
+// valuesList renders a slice as a VALUES constructor on PostgreSQL, which
+// plans `IN (VALUES ...)` as a semi-join, and as a regular IN list elsewhere.
+type valuesList struct {
+	slice reflect.Value
+	in    schema.QueryAppender
+}
+
+var _ schema.QueryAppender = (*valuesList)(nil)
+
+func (v *valuesList) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte, err error) {
+	if !fmter.HasFeature(feature.InValues) {
+		return v.in.AppendQuery(fmter, b)
+	}
+
+	b = append(b, "VALUES "...)
+	for i := 0; i < v.slice.Len(); i++ {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+
+		elem := v.slice.Index(i)
+		if elem.Kind() == reflect.Interface {
+			elem = elem.Elem()
+		}
+
+		b = append(b, '(')
+		if elem.Kind() == reflect.Slice && elem.Type() != bytesType {
+			b, err = In(elem.Interface()).AppendQuery(fmter, b)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			b = fmter.AppendValue(b, elem)
+		}
+		b = append(b, ')')
+	}
+	return b, nil
+}
+
+// whereInChunkSize is the max number of values in one IN list of WhereInChunked.
+// It matches the Oracle limit on the number of IN list items.
+const whereInChunkSize = 1000
+
+func (q *whereBaseQuery) addWhereInChunked(column string, values interface{}) {
+	slice := reflect.ValueOf(values)
+	if slice.Kind() != reflect.Slice {
+		q.setErr(fmt.Errorf("bun: WhereInChunked(non-slice %T)", values))
+		return
+	}
+
+	// Empty lists are left to In so the DB EmptyInPolicy still applies.
+	if slice.Len() <= whereInChunkSize {
+		q.addWhere(schema.SafeQueryWithSep(
+			"? IN (?)",
+			[]interface{}{schema.Ident(column), In(values)},
+			" AND ",
+		))
+		return
+	}
+
+	where := make([]schema.QueryWithSep, 0, slice.Len()/whereInChunkSize+1)
+	for i := 0; i < slice.Len(); i += whereInChunkSize {
+		j := i + whereInChunkSize
+		if j > slice.Len() {
+			j = slice.Len()
+		}
+		where = append(where, schema.SafeQueryWithSep(
+			"? IN (?)",
+			[]interface{}{schema.Ident(column), In(slice.Slice(i, j).Interface())},
+			" OR ",
+		))
+	}
+	q.addWhereGroup(" AND ", where)
+}
+
+func (q *whereBaseQuery) addWhereSTDWithin(column string, lon, lat, meters float64) {
+	if !(lon >= -180 && lon <= 180) || !(lat >= -90 && lat <= 90) {
+		q.setErr(fmt.Errorf("bun: WhereSTDWithin got invalid coordinates (%v, %v)", lon, lat))
+		return
+	}
+	if !(meters >= 0) {
+		q.setErr(fmt.Errorf("bun: WhereSTDWithin got invalid distance %v", meters))
+		return
+	}
+	if !q.hasFeature(feature.PostGIS) {
+		q.setErr(fmt.Errorf("bun: %s does not support ST_DWithin", q.db.Dialect().Name()))
+		return
+	}
+	q.addWhere(schema.SafeQueryWithSep("?", []interface{}{&stDWithin{
+		column: column,
+		lon:    lon,
+		lat:    lat,
+		meters: meters,
+	}}, " AND "))
+}
+
+// stDWithin renders the PostGIS ST_DWithin function. Both the column and
+// the point are cast to geography, so coordinates are WGS 84 (SRID 4326)
+// and the distance is in meters.
+type stDWithin struct {
+	column   string
+	lon, lat float64
+	meters   float64
+}
+
+var _ schema.QueryAppender = (*stDWithin)(nil)
+
+func (d *stDWithin) AppendQuery(fmter schema.Formatter, b []byte) ([]byte, error) {
+	if !fmter.HasFeature(feature.PostGIS) {
+		return nil, fmt.Errorf("bun: %s does not support ST_DWithin", fmter.Dialect().Name())
+	}
+
+	b = append(b, "ST_DWithin("...)
+	b = fmter.AppendIdent(b, d.column)
+	b = append(b, "::geography, ST_SetSRID(ST_MakePoint("...)
+	b = strconv.AppendFloat(b, d.lon, 'f', -1, 64)
+	b = append(b, ", "...)
+	b = strconv.AppendFloat(b, d.lat, 'f', -1, 64)
+	b = append(b, "), 4326)::geography, "...)
+	b = strconv.AppendFloat(b, d.meters, 'f', -1, 64)
+	b = append(b, ')')
+	return b, nil
+}
+
+func (q *whereBaseQuery) addWhereTrigramSimilar(column, query string, threshold float64) {
+	if !(threshold >= 0 && threshold <= 1) {
+		q.setErr(fmt.Errorf("bun: WhereTrigramSimilar got threshold %v, wanted a value from 0 to 1", threshold))
+		return
+	}
+	if !q.hasFeature(feature.Trigram) {
+		q.setErr(fmt.Errorf("bun: %s does not support pg_trgm", q.db.Dialect().Name()))
+		return
+	}
+	q.addWhere(schema.SafeQueryWithSep("?", []interface{}{&trigramExpr{
+		column:    column,
+		query:     query,
+		threshold: threshold,
+	}}, " AND "))
+}
+
+// trigramExpr renders pg_trgm expressions. As a condition, it uses the %
+// operator, which can use a trigram index, and also compares similarity with
+// the threshold when it is set. As an order, it renders similarity DESC.
+type trigramExpr struct {
+	column    string
+	query     string
+	threshold float64
+	order     bool
+}
+
+var _ schema.QueryAppender = (*trigramExpr)(nil)
+
+func (t *trigramExpr) AppendQuery(fmter schema.Formatter, b []byte) ([]byte, error) {
+	if !fmter.HasFeature(feature.Trigram) {
+		return nil, fmt.Errorf("bun: %s does not support pg_trgm", fmter.Dialect().Name())
+	}
+
+	if t.order {
+		b = t.appendSimilarity(fmter, b)
+		b = append(b, " DESC"...)
+		return b, nil
+	}
+
+	b = fmter.AppendIdent(b, t.column)
+	b = append(b, " % "...)
+	b = fmter.Dialect().AppendString(b, t.query)
+	if t.threshold > 0 {
+		b = append(b, " AND "...)
+		b = t.appendSimilarity(fmter, b)
+		b = append(b, " >= "...)
+		b = strconv.AppendFloat(b, t.threshold, 'f', -1, 64)
+	}
+	return b, nil
+}
+
+func (t *trigramExpr) appendSimilarity(fmter schema.Formatter, b []byte) []byte {
+	b = append(b, "similarity("...)
+	b = fmter.AppendIdent(b, t.column)
+	b = append(b, ", "...)
+	b = fmter.Dialect().AppendString(b, t.query)
+	b = append(b, ')')
+	return b
+}
+
+func (q *whereBaseQuery) addWhereRaw(query string) {
+	if !q.checkSafeQuery(schema.SafeQuery(query, nil)) {
+		return
+	}
+	q.addWhere(schema.SafeQueryWithSep("?", []interface{}{schema.Safe(query)}, " AND "))
+}
+
 func (q *whereBaseQuery) addWhereCols(cols []string) {
 	if q.table == nil {
 		err := fmt.Errorf("bun: got %T, but WherePK requires a struct or slice-based model", q.model)
@@ -767,24 +1475,41 @@ func (q *whereBaseQuery) mustAppendWhere(
 		err := errors.New("bun: Update and Delete queries require at least one Where")
 		return nil, err
 	}
-	return q.appendWhere(fmter, b, withAlias)
+	return q._appendWhere(fmter, b, q.where, withAlias)
 }
 
 func (q *whereBaseQuery) appendWhere(
 	fmter schema.Formatter, b []byte, withAlias bool,
 ) (_ []byte, err error) {
-	if len(q.where) == 0 && q.whereFields == nil && !q.isSoftDelete() {
+	return q._appendWhere(fmter, b, q.where, withAlias)
+}
+
+func (q *whereBaseQuery) _appendWhere(
+	fmter schema.Formatter, b []byte, where []schema.QueryWithSep, withAlias bool,
+) (_ []byte, err error) {
+	if len(where) == 0 && q.whereFields == nil && !q.isSoftDelete() {
 		return b, nil
 	}
 
 	b = append(b, " WHERE "...)
 	startLen := len(b)
 
-	if len(q.where) > 0 {
-		b, err = appendWhere(fmter, b, q.where)
+	if len(where) > 0 {
+		// Group the conditions so the predicates appended below are not
+		// bound only to the last operand of OR.
+		group := (q.isSoftDelete() || q.whereFields != nil) && hasTopLevelOr(where)
+		if group {
+			b = append(b, '(')
+		}
+
+		b, err = appendWhere(fmter, b, where)
 		if err != nil {
 			return nil, err
 		}
+
+		if group {
+			b = append(b, ')')
+		}
 	}
 
 	if q.isSoftDelete() {
@@ -792,17 +1517,22 @@ func (q *whereBaseQuery) appendWhere(
 			b = append(b, " AND "...)
 		}
 
+		colStart := len(b)
 		if withAlias {
 			b = append(b, q.tableModel.Table().SQLAlias...)
 		} else {
-			b = append(b, q.tableModel.Table().SQLName...)
+			table := q.tableModel.Table()
+			b = q.appendTableName(fmter, b, table, table.SQLName)
 		}
 		b = append(b, '.')
 
 		field := q.tableModel.Table().SoftDeleteField
 		b = append(b, field.SQLName...)
 
-		if field.IsPtr || field.NullZero {
+		if q.tableModel.Table().SoftDeleteBool {
+			column := append([]byte(nil), b[colStart:]...)
+			b = appendSoftDeleteBool(fmter, b[:colStart], column, q.flags.Has(deletedFlag))
+		} else if field.IsPtr || field.NullZero {
 			if q.flags.Has(deletedFlag) {
 				b = append(b, " IS NOT NULL"...)
 			} else {
@@ -831,6 +1561,27 @@ func (q *whereBaseQuery) appendWhere(
 	return b, nil
 }
 
+// appendSoftDeleteBool appends the predicate for soft_delete:bool columns.
+// NULL is treated as not deleted so the three-valued IS [NOT] TRUE is used.
+func appendSoftDeleteBool(fmter schema.Formatter, b, column []byte, deleted bool) []byte {
+	if !fmter.HasFeature(feature.BoolType) {
+		// For example, SQL Server does not support IS TRUE.
+		if deleted {
+			b = append(b, column...)
+			return append(b, " = 1"...)
+		}
+		b = append(b, "ISNULL("...)
+		b = append(b, column...)
+		return append(b, ", 0) = 0"...)
+	}
+
+	b = append(b, column...)
+	if deleted {
+		return append(b, " IS TRUE"...)
+	}
+	return append(b, " IS NOT TRUE"...)
+}
+
 func appendWhere(
 	fmter schema.Formatter, b []byte, where []schema.QueryWithSep,
 ) (_ []byte, err error) {
@@ -853,6 +1604,35 @@ func appendWhere(
 	return b, nil
 }
 
+func isIdentByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_' || c == '$'
+}
+
+func isWhereGroupSep(where schema.QueryWithSep) bool {
+	return where.Query == "" && where.Sep != "(" && where.Sep != ")"
+}
+
+// hasTopLevelOr reports whether any of the conditions outside of
+// WhereGroup parentheses are joined with OR.
+func hasTopLevelOr(where []schema.QueryWithSep) bool {
+	var depth int
+	for i, where := range where {
+		switch where.Sep {
+		case "(":
+			depth++
+			continue
+		case ")":
+			depth--
+			continue
+		}
+		if i > 0 && depth == 0 && strings.Contains(strings.ToUpper(where.Sep), "OR") {
+			return true
+		}
+	}
+	return false
+}
+
 func (q *whereBaseQuery) appendWhereFields(
 	fmter schema.Formatter, b []byte, fields []*schema.Field, withAlias bool,
 ) (_ []byte, err error) {
@@ -911,6 +1691,15 @@ func (q *whereBaseQuery) appendWhereSliceFields(
 	fields []*schema.Field,
 	withAlias bool,
 ) (_ []byte, err error) {
+	if model.slice.Len() == 0 {
+		switch q.db.emptyInPolicy {
+		case EmptyInError:
+			return nil, errors.New("bun: WherePK got an empty slice")
+		case EmptyInFalseLiteral:
+			return append(b, "1 = 0"...), nil
+		}
+	}
+
 	if len(fields) > 1 {
 		b = append(b, '(')
 	}
@@ -928,31 +1717,31 @@ func (q *whereBaseQuery) appendWhereSliceFields(
 	isTemplate := fmter.IsNop()
 	slice := model.slice
 	sliceLen := slice.Len()
-	for i := 0; i < sliceLen; i++ {
-		if i > 0 {
-			if isTemplate {
-				break
-			}
-			b = append(b, ", "...)
-		}
-
-		el := indirect(slice.Index(i))
 
-		if len(fields) > 1 {
-			b = append(b, '(')
+	if q.flags.Has(wherePKSortedFlag) && !isTemplate {
+		tuples := make([][]byte, sliceLen)
+		for i := range tuples {
+			tuples[i] = appendWhereTuple(fmter, nil, fields, indirect(slice.Index(i)), false)
 		}
-		for i, f := range fields {
+		sort.Slice(tuples, func(i, j int) bool {
+			return bytes.Compare(tuples[i], tuples[j]) < 0
+		})
+
+		for i, tuple := range tuples {
 			if i > 0 {
 				b = append(b, ", "...)
 			}
-			if isTemplate {
-				b = append(b, '?')
-			} else {
-				b = f.AppendValue(fmter, b, el)
-			}
+			b = append(b, tuple...)
 		}
-		if len(fields) > 1 {
-			b = append(b, ')')
+	} else {
+		for i := 0; i < sliceLen; i++ {
+			if i > 0 {
+				if isTemplate {
+					break
+				}
+				b = append(b, ", "...)
+			}
+			b = appendWhereTuple(fmter, b, fields, indirect(slice.Index(i)), isTemplate)
 		}
 	}
 
@@ -961,19 +1750,47 @@ func (q *whereBaseQuery) appendWhereSliceFields(
 	return b, nil
 }
 
+func appendWhereTuple(
+	fmter schema.Formatter, b []byte, fields []*schema.Field, el reflect.Value, isTemplate bool,
+) []byte {
+	if len(fields) > 1 {
+		b = append(b, '(')
+	}
+	for i, f := range fields {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		if isTemplate {
+			b = append(b, '?')
+		} else {
+			b = f.AppendValue(fmter, b, el)
+		}
+	}
+	if len(fields) > 1 {
+		b = append(b, ')')
+	}
+	return b
+}
+
 //------------------------------------------------------------------------------
 
 type returningQuery struct {
 	returning       []schema.QueryWithArgs
 	returningFields []*schema.Field
+	returningAll    bool
 }
 
 func (q *returningQuery) addReturning(ret schema.QueryWithArgs) {
 	q.returning = append(q.returning, ret)
 }
 
+func (q *returningQuery) addReturningAll(table *schema.Table) {
+	q.returningAll = true
+	q.returningFields = table.Fields
+}
+
 func (q *returningQuery) addReturningField(field *schema.Field) {
-	if len(q.returning) > 0 {
+	if len(q.returning) > 0 || q.returningAll {
 		return
 	}
 	for _, f := range q.returningFields {
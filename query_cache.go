@@ -0,0 +1,274 @@
+package bun
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/uptrace/bun/schema"
+)
+
+// queryCache maps the structure of queries, that is, query strings, separators,
+// limits, and the model table without the argument values, to query templates.
+// Queries built the same way only append the argument values to the template
+// instead of formatting every clause again.
+type queryCache struct {
+	size int
+
+	mu        sync.RWMutex
+	len       int
+	templates map[*schema.Table]map[string]*queryTemplate
+}
+
+func newQueryCache(size int) *queryCache {
+	return &queryCache{
+		size:      size,
+		templates: make(map[*schema.Table]map[string]*queryTemplate),
+	}
+}
+
+// clone returns an empty cache of the same size, because copies of the DB
+// may format the same queries differently, for example, with a table prefix.
+func (c *queryCache) clone() *queryCache {
+	if c == nil {
+		return nil
+	}
+	return newQueryCache(c.size)
+}
+
+// get returns the template for the query shape. A nil template means
+// that the query can't be cached.
+func (c *queryCache) get(table *schema.Table, key []byte) (*queryTemplate, bool) {
+	c.mu.RLock()
+	tmpl, ok := c.templates[table][string(key)]
+	c.mu.RUnlock()
+	return tmpl, ok
+}
+
+// full reports whether the cache has reached its size. Templates are never
+// evicted, so the queries that didn't fit are formatted without the cache.
+func (c *queryCache) full() bool {
+	c.mu.RLock()
+	full := c.len >= c.size
+	c.mu.RUnlock()
+	return full
+}
+
+func (c *queryCache) add(table *schema.Table, key string, tmpl *queryTemplate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.len >= c.size {
+		return
+	}
+
+	m, ok := c.templates[table]
+	if !ok {
+		m = make(map[string]*queryTemplate)
+		c.templates[table] = m
+	}
+	if _, ok := m[key]; !ok {
+		m[key] = tmpl
+		c.len++
+	}
+}
+
+// appendQuery appends the query using the cached template for the shape.
+// On a cache miss, the query is formatted with the arguments left out and
+// the result is cached unless the cache is full.
+func (c *queryCache) appendQuery(
+	fmter schema.Formatter,
+	b []byte,
+	query schema.QueryAppender,
+	table *schema.Table,
+	shape *queryShape,
+) (_ []byte, err error) {
+	tmpl, ok := c.get(table, shape.key)
+	if ok {
+		if tmpl == nil {
+			return query.AppendQuery(fmter, b)
+		}
+		return tmpl.bind(fmter, b, shape.args), nil
+	}
+	if c.full() {
+		return query.AppendQuery(fmter, b)
+	}
+
+	var rec schema.ArgRecorder
+	queryBytes, err := query.AppendQuery(fmter.WithArgRecorder(&rec), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c.add(table, string(shape.key), newQueryTemplate(queryBytes, &rec, shape))
+
+	var pos int
+	for i, arg := range rec.Args {
+		b = append(b, queryBytes[pos:rec.Offsets[i]]...)
+		b = schema.Append(fmter, b, *arg)
+		pos = rec.Offsets[i]
+	}
+	return append(b, queryBytes[pos:]...), nil
+}
+
+//------------------------------------------------------------------------------
+
+type queryTemplate struct {
+	query []byte
+	args  []templateArg
+}
+
+type templateArg struct {
+	offset int
+	// index is the index of the argument in queryShape.args.
+	index int
+}
+
+// newQueryTemplate returns nil if the query depends on values that are not
+// part of the shape, for example, struct fields referenced by ?name.
+func newQueryTemplate(query []byte, rec *schema.ArgRecorder, shape *queryShape) *queryTemplate {
+	if rec.Inline {
+		return nil
+	}
+
+	tmpl := &queryTemplate{
+		query: query,
+		args:  make([]templateArg, len(rec.Args)),
+	}
+	for i, arg := range rec.Args {
+		index := shape.argIndex(arg)
+		if index == -1 {
+			return nil
+		}
+		tmpl.args[i] = templateArg{
+			offset: rec.Offsets[i],
+			index:  index,
+		}
+	}
+	return tmpl
+}
+
+func (t *queryTemplate) bind(fmter schema.Formatter, b []byte, args []interface{}) []byte {
+	var pos int
+	for _, arg := range t.args {
+		b = append(b, t.query[pos:arg.offset]...)
+		b = schema.Append(fmter, b, args[arg.index])
+		pos = arg.offset
+	}
+	return append(b, t.query[pos:]...)
+}
+
+//------------------------------------------------------------------------------
+
+var queryShapePool = sync.Pool{
+	New: func() interface{} {
+		return new(queryShape)
+	},
+}
+
+func getQueryShape() *queryShape {
+	return queryShapePool.Get().(*queryShape)
+}
+
+func putQueryShape(s *queryShape) {
+	s.key = s.key[:0]
+	for i := range s.args {
+		s.args[i] = nil
+		s.ptrs[i] = nil
+	}
+	s.args = s.args[:0]
+	s.ptrs = s.ptrs[:0]
+	queryShapePool.Put(s)
+}
+
+// queryShape is the structure of a query without the argument values.
+type queryShape struct {
+	key []byte
+	// args are the values of the arguments left out of the key.
+	args []interface{}
+	// ptrs are the addresses of args in the query, which are used to match
+	// the arguments recorded by schema.ArgRecorder.
+	ptrs []*interface{}
+}
+
+func (s *queryShape) argIndex(arg *interface{}) int {
+	for i, ptr := range s.ptrs {
+		if ptr == arg {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *queryShape) addInt(n int64) {
+	var buf [binary.MaxVarintLen64]byte
+	s.key = append(s.key, buf[:binary.PutVarint(buf[:], n)]...)
+}
+
+func (s *queryShape) addBool(flag bool) {
+	if flag {
+		s.key = append(s.key, 1)
+	} else {
+		s.key = append(s.key, 0)
+	}
+}
+
+func (s *queryShape) addString(str string) {
+	s.addInt(int64(len(str)))
+	s.key = append(s.key, str...)
+}
+
+// addQuery adds the query and the arguments that change the query structure,
+// for example, Ident. It returns false if an argument is another query appender,
+// for example, a subquery or In, because its structure is not known.
+func (s *queryShape) addQuery(q schema.QueryWithArgs) bool {
+	s.addString(q.Query)
+	if q.Args == nil {
+		s.addInt(-1)
+		return true
+	}
+
+	s.addInt(int64(len(q.Args)))
+	for i := range q.Args {
+		switch arg := q.Args[i].(type) {
+		case schema.Ident:
+			s.key = append(s.key, 'i')
+			s.addString(string(arg))
+		case schema.Safe:
+			s.key = append(s.key, 's')
+			s.addString(string(arg))
+		case schema.QueryAppender, schema.NamedArgAppender:
+			return false
+		default:
+			s.key = append(s.key, 'v')
+			s.args = append(s.args, arg)
+			s.ptrs = append(s.ptrs, &q.Args[i])
+		}
+	}
+	return true
+}
+
+func (s *queryShape) addQueries(queries []schema.QueryWithArgs) bool {
+	if queries == nil {
+		s.addInt(-1)
+		return true
+	}
+
+	s.addInt(int64(len(queries)))
+	for _, q := range queries {
+		if !s.addQuery(q) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *queryShape) addQueriesWithSep(queries []schema.QueryWithSep) bool {
+	s.addInt(int64(len(queries)))
+	for _, q := range queries {
+		s.addString(q.Sep)
+		if !s.addQuery(q.QueryWithArgs) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,98 @@
+package bun
+
+import (
+	"errors"
+
+	"github.com/uptrace/bun/schema"
+)
+
+// CaseExpr builds a CASE expression that can be passed to ColumnExpr:
+//
+//    q.ColumnExpr("?", bun.Case().
+//        When("price > ?", []interface{}{100}, "?", []interface{}{"expensive"}).
+//        Else("?", "cheap").
+//        As("category"))
+type CaseExpr struct {
+	whens []caseWhen
+	els   schema.QueryWithArgs
+	alias string
+}
+
+type caseWhen struct {
+	cond schema.QueryWithArgs
+	then schema.QueryWithArgs
+}
+
+var (
+	_ schema.QueryAppender = (*CaseExpr)(nil)
+	_ dialectChecker       = (*CaseExpr)(nil)
+)
+
+func Case() *CaseExpr {
+	return new(CaseExpr)
+}
+
+func (c *CaseExpr) When(
+	cond string, condArgs []interface{}, then string, thenArgs []interface{},
+) *CaseExpr {
+	c.whens = append(c.whens, caseWhen{
+		cond: schema.SafeQuery(cond, condArgs),
+		then: schema.SafeQuery(then, thenArgs),
+	})
+	return c
+}
+
+func (c *CaseExpr) Else(query string, args ...interface{}) *CaseExpr {
+	c.els = schema.SafeQuery(query, args)
+	return c
+}
+
+func (c *CaseExpr) As(alias string) *CaseExpr {
+	c.alias = alias
+	return c
+}
+
+func (c *CaseExpr) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte, err error) {
+	if err := c.checkDialect(fmter.Dialect()); err != nil {
+		return nil, err
+	}
+
+	b = append(b, "CASE"...)
+	for _, w := range c.whens {
+		b = append(b, " WHEN "...)
+		b, err = w.cond.AppendQuery(fmter, b)
+		if err != nil {
+			return nil, err
+		}
+
+		b = append(b, " THEN "...)
+		b, err = w.then.AppendQuery(fmter, b)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !c.els.IsZero() {
+		b = append(b, " ELSE "...)
+		b, err = c.els.AppendQuery(fmter, b)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	b = append(b, " END"...)
+
+	if c.alias != "" {
+		b = append(b, " AS "...)
+		b = fmter.AppendIdent(b, c.alias)
+	}
+
+	return b, nil
+}
+
+func (c *CaseExpr) checkDialect(d schema.Dialect) error {
+	if len(c.whens) == 0 {
+		return errors.New("bun: CASE requires at least one WHEN")
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package bun
+
+import (
+	"strings"
+
+	"github.com/uptrace/bun/dialect/feature"
+	"github.com/uptrace/bun/schema"
+)
+
+// Cast returns an expression that casts expr to the SQL type using
+// the dialect syntax, for example:
+//
+//    q.ColumnExpr("? AS num", bun.Cast("str", "integer"))
+//
+// PostgreSQL renders `str::integer`, MySQL `CAST(str AS SIGNED)`,
+// and other dialects `CAST(str AS integer)`.
+func Cast(expr, sqlType string) schema.QueryWithArgs {
+	return schema.SafeQuery("?", []interface{}{&castExpr{
+		expr:    expr,
+		sqlType: sqlType,
+	}})
+}
+
+type castExpr struct {
+	expr    string
+	sqlType string
+}
+
+var _ schema.QueryAppender = (*castExpr)(nil)
+
+func (c *castExpr) AppendQuery(fmter schema.Formatter, b []byte) ([]byte, error) {
+	switch {
+	case fmter.HasFeature(feature.DoubleColonCast):
+		if isSimpleExpr(c.expr) {
+			b = append(b, c.expr...)
+		} else {
+			b = append(b, '(')
+			b = append(b, c.expr...)
+			b = append(b, ')')
+		}
+		b = append(b, "::"...)
+		b = append(b, c.sqlType...)
+	case fmter.HasFeature(feature.CastAsSigned):
+		b = append(b, "CAST("...)
+		b = append(b, c.expr...)
+		b = append(b, " AS "...)
+		b = append(b, mysqlCastType(c.sqlType)...)
+		b = append(b, ')')
+	default:
+		b = append(b, "CAST("...)
+		b = append(b, c.expr...)
+		b = append(b, " AS "...)
+		b = append(b, c.sqlType...)
+		b = append(b, ')')
+	}
+	return b, nil
+}
+
+// isSimpleExpr reports whether the expression is a column name or a literal
+// that does not need parentheses before the PostgreSQL :: operator.
+func isSimpleExpr(expr string) bool {
+	if expr == "" {
+		return false
+	}
+	for i := 0; i < len(expr); i++ {
+		switch c := expr[i]; {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '_', c == '.', c == '"':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// mysqlCastType maps the SQL type to the types supported by MySQL CAST.
+func mysqlCastType(sqlType string) string {
+	switch strings.ToLower(sqlType) {
+	case "int", "integer", "smallint", "bigint":
+		return "SIGNED"
+	case "text", "varchar":
+		return "CHAR"
+	default:
+		return sqlType
+	}
+}
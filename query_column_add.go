@@ -13,6 +13,8 @@ type AddColumnQuery struct {
 	baseQuery
 
 	ifNotExists bool
+	notNull     bool
+	defaultExpr schema.QueryWithArgs
 }
 
 var _ Query = (*AddColumnQuery)(nil)
@@ -63,6 +65,24 @@ func (q *AddColumnQuery) ColumnExpr(query string, args ...interface{}) *AddColum
 	return q
 }
 
+// AddColumn adds the column with the SQL type, for example:
+//
+//    db.NewAddColumn().Table("users").AddColumn("status", "varchar(20)").NotNull().Default("?", "active")
+func (q *AddColumnQuery) AddColumn(name, sqlType string) *AddColumnQuery {
+	q.addColumn(schema.SafeQuery("? ?", []interface{}{Ident(name), Safe(sqlType)}))
+	return q
+}
+
+func (q *AddColumnQuery) NotNull() *AddColumnQuery {
+	q.notNull = true
+	return q
+}
+
+func (q *AddColumnQuery) Default(query string, args ...interface{}) *AddColumnQuery {
+	q.defaultExpr = schema.SafeQuery(query, args)
+	return q
+}
+
 func (q *AddColumnQuery) IfNotExists() *AddColumnQuery {
 	q.ifNotExists = true
 	return q
@@ -100,6 +120,18 @@ func (q *AddColumnQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte
 		return nil, err
 	}
 
+	if q.notNull {
+		b = append(b, " NOT NULL"...)
+	}
+
+	if !q.defaultExpr.IsZero() {
+		b = append(b, " DEFAULT "...)
+		b, err = q.defaultExpr.AppendQuery(fmter, b)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return b, nil
 }
 
@@ -0,0 +1,249 @@
+package bun
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/uptrace/bun/dialect/feature"
+	"github.com/uptrace/bun/internal"
+	"github.com/uptrace/bun/schema"
+)
+
+type AlterColumnQuery struct {
+	baseQuery
+
+	column      schema.QueryWithArgs
+	setDefault  schema.QueryWithArgs
+	dropDefault bool
+	newName     schema.QueryWithArgs
+	sqlType     string
+}
+
+var _ Query = (*AlterColumnQuery)(nil)
+
+func NewAlterColumnQuery(db *DB) *AlterColumnQuery {
+	q := &AlterColumnQuery{
+		baseQuery: baseQuery{
+			db:   db,
+			conn: db.DB,
+		},
+	}
+	return q
+}
+
+func (q *AlterColumnQuery) Conn(db IConn) *AlterColumnQuery {
+	q.setConn(db)
+	return q
+}
+
+func (q *AlterColumnQuery) Model(model interface{}) *AlterColumnQuery {
+	q.setTableModel(model)
+	return q
+}
+
+//------------------------------------------------------------------------------
+
+func (q *AlterColumnQuery) Table(tables ...string) *AlterColumnQuery {
+	for _, table := range tables {
+		q.addTable(schema.UnsafeIdent(table))
+	}
+	return q
+}
+
+func (q *AlterColumnQuery) TableExpr(query string, args ...interface{}) *AlterColumnQuery {
+	q.addTable(schema.SafeQuery(query, args))
+	return q
+}
+
+func (q *AlterColumnQuery) ModelTableExpr(query string, args ...interface{}) *AlterColumnQuery {
+	q.modelTableName = schema.SafeQuery(query, args)
+	return q
+}
+
+//------------------------------------------------------------------------------
+
+func (q *AlterColumnQuery) Column(column string) *AlterColumnQuery {
+	q.column = schema.UnsafeIdent(column)
+	return q
+}
+
+// SetDefault sets the column default, for example:
+//
+//    db.NewAlterColumn().Table("users").Column("status").SetDefault("?", "active")
+func (q *AlterColumnQuery) SetDefault(query string, args ...interface{}) *AlterColumnQuery {
+	q.setDefault = schema.SafeQuery(query, args)
+	return q
+}
+
+// DropDefault removes the column default.
+func (q *AlterColumnQuery) DropDefault() *AlterColumnQuery {
+	q.dropDefault = true
+	return q
+}
+
+// RenameColumn renames the column, for example:
+//
+//    db.NewAlterColumn().Table("users").RenameColumn("name", "full_name")
+//
+// MySQL before 8.0 only supports CHANGE, which requires the column type set with Type.
+func (q *AlterColumnQuery) RenameColumn(oldName, newName string) *AlterColumnQuery {
+	q.column = schema.UnsafeIdent(oldName)
+	q.newName = schema.UnsafeIdent(newName)
+	return q
+}
+
+// Type sets the full column type, for example, "varchar(100) NOT NULL",
+// that is required to rename a column on MySQL before 8.0.
+func (q *AlterColumnQuery) Type(sqlType string) *AlterColumnQuery {
+	q.sqlType = sqlType
+	return q
+}
+
+//------------------------------------------------------------------------------
+
+func (q *AlterColumnQuery) Operation() string {
+	return "ALTER COLUMN"
+}
+
+func (q *AlterColumnQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte, err error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+
+	if q.column.IsZero() {
+		return nil, errors.New("bun: AlterColumnQuery requires Column")
+	}
+
+	if !q.newName.IsZero() {
+		if !q.setDefault.IsZero() || q.dropDefault {
+			return nil, errors.New("bun: RenameColumn can't be used with SetDefault or DropDefault")
+		}
+		return q.appendRename(fmter, b)
+	}
+
+	if !fmter.HasFeature(feature.AlterColumnDefault) {
+		return nil, fmt.Errorf("bun: %s does not support altering column defaults",
+			fmter.Dialect().Name())
+	}
+
+	if q.setDefault.IsZero() == !q.dropDefault {
+		return nil, errors.New("bun: AlterColumnQuery requires either SetDefault or DropDefault")
+	}
+
+	b = append(b, "ALTER TABLE "...)
+
+	b, err = q.appendFirstTable(fmter, b)
+	if err != nil {
+		return nil, err
+	}
+
+	b = append(b, " ALTER COLUMN "...)
+
+	b, err = q.column.AppendQuery(fmter, b)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.dropDefault {
+		b = append(b, " DROP DEFAULT"...)
+		return b, nil
+	}
+
+	b = append(b, " SET DEFAULT "...)
+
+	b, err = q.setDefault.AppendQuery(fmter, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (q *AlterColumnQuery) appendRename(fmter schema.Formatter, b []byte) (_ []byte, err error) {
+	if fmter.HasFeature(feature.SpRename) {
+		// EXEC sp_rename 'table.old_name', 'new_name', 'COLUMN'
+		var obj []byte
+		obj, err = q.appendFirstTable(fmter, obj)
+		if err != nil {
+			return nil, err
+		}
+		obj = append(obj, '.')
+		obj, err = q.column.AppendQuery(fmter, obj)
+		if err != nil {
+			return nil, err
+		}
+
+		b = append(b, "EXEC sp_rename "...)
+		b = fmter.Dialect().AppendString(b, internal.String(obj))
+		b = append(b, ", "...)
+		b = fmter.Dialect().AppendString(b, q.newName.Query)
+		b = append(b, ", 'COLUMN'"...)
+		return b, nil
+	}
+
+	change := !fmter.HasFeature(feature.RenameColumn)
+	if change {
+		if !fmter.HasFeature(feature.ChangeColumn) {
+			return nil, fmt.Errorf("bun: %s does not support renaming columns", fmter.Dialect().Name())
+		}
+		if q.sqlType == "" {
+			return nil, errors.New("bun: renaming a column with CHANGE requires Type")
+		}
+	}
+
+	b = append(b, "ALTER TABLE "...)
+
+	b, err = q.appendFirstTable(fmter, b)
+	if err != nil {
+		return nil, err
+	}
+
+	if change {
+		b = append(b, " CHANGE "...)
+	} else {
+		b = append(b, " RENAME COLUMN "...)
+	}
+
+	b, err = q.column.AppendQuery(fmter, b)
+	if err != nil {
+		return nil, err
+	}
+
+	if change {
+		b = append(b, ' ')
+	} else {
+		b = append(b, " TO "...)
+	}
+
+	b, err = q.newName.AppendQuery(fmter, b)
+	if err != nil {
+		return nil, err
+	}
+
+	if change {
+		b = append(b, ' ')
+		b = append(b, q.sqlType...)
+	}
+
+	return b, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (q *AlterColumnQuery) Exec(ctx context.Context, dest ...interface{}) (sql.Result, error) {
+	queryBytes, err := q.AppendQuery(q.db.fmter, q.db.makeQueryBytes())
+	if err != nil {
+		return nil, err
+	}
+
+	query := internal.String(queryBytes)
+
+	res, err := q.exec(ctx, q, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
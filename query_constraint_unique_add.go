@@ -0,0 +1,177 @@
+package bun
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/uptrace/bun/dialect/feature"
+	"github.com/uptrace/bun/internal"
+	"github.com/uptrace/bun/schema"
+)
+
+// AddUniqueConstraintQuery adds a unique constraint to a table, for example:
+//
+//    db.NewAddUniqueConstraint().
+//        Model((*Book)(nil)).
+//        Constraint("books_isbn_key").
+//        Column("isbn").
+//        InitiallyDeferred()
+//
+// Unlike a unique index, a constraint can be DEFERRABLE, which is only
+// supported by PostgreSQL. SQLite can't add constraints to existing tables.
+type AddUniqueConstraintQuery struct {
+	baseQuery
+
+	name              schema.QueryWithArgs
+	deferrable        bool
+	initiallyDeferred bool
+}
+
+var _ Query = (*AddUniqueConstraintQuery)(nil)
+
+func NewAddUniqueConstraintQuery(db *DB) *AddUniqueConstraintQuery {
+	q := &AddUniqueConstraintQuery{
+		baseQuery: baseQuery{
+			db:   db,
+			conn: db.DB,
+		},
+	}
+	return q
+}
+
+func (q *AddUniqueConstraintQuery) Conn(db IConn) *AddUniqueConstraintQuery {
+	q.setConn(db)
+	return q
+}
+
+func (q *AddUniqueConstraintQuery) Model(model interface{}) *AddUniqueConstraintQuery {
+	q.setTableModel(model)
+	return q
+}
+
+//------------------------------------------------------------------------------
+
+func (q *AddUniqueConstraintQuery) Table(tables ...string) *AddUniqueConstraintQuery {
+	for _, table := range tables {
+		q.addTable(schema.UnsafeIdent(table))
+	}
+	return q
+}
+
+func (q *AddUniqueConstraintQuery) TableExpr(query string, args ...interface{}) *AddUniqueConstraintQuery {
+	q.addTable(schema.SafeQuery(query, args))
+	return q
+}
+
+func (q *AddUniqueConstraintQuery) ModelTableExpr(query string, args ...interface{}) *AddUniqueConstraintQuery {
+	q.modelTableName = schema.SafeQuery(query, args)
+	return q
+}
+
+//------------------------------------------------------------------------------
+
+// Constraint sets the constraint name. Without it, the database generates one.
+func (q *AddUniqueConstraintQuery) Constraint(name string) *AddUniqueConstraintQuery {
+	q.name = schema.UnsafeIdent(name)
+	return q
+}
+
+func (q *AddUniqueConstraintQuery) Column(columns ...string) *AddUniqueConstraintQuery {
+	for _, column := range columns {
+		q.addColumn(schema.UnsafeIdent(column))
+	}
+	return q
+}
+
+func (q *AddUniqueConstraintQuery) ColumnExpr(query string, args ...interface{}) *AddUniqueConstraintQuery {
+	q.addColumn(schema.SafeQuery(query, args))
+	return q
+}
+
+// Deferrable allows the check to be deferred with SET CONSTRAINTS.
+func (q *AddUniqueConstraintQuery) Deferrable() *AddUniqueConstraintQuery {
+	q.deferrable = true
+	return q
+}
+
+// InitiallyDeferred makes the constraint deferrable and checks it
+// at the end of each transaction by default.
+func (q *AddUniqueConstraintQuery) InitiallyDeferred() *AddUniqueConstraintQuery {
+	q.deferrable = true
+	q.initiallyDeferred = true
+	return q
+}
+
+//------------------------------------------------------------------------------
+
+func (q *AddUniqueConstraintQuery) Operation() string {
+	return "ADD CONSTRAINT"
+}
+
+func (q *AddUniqueConstraintQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte, err error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	if len(q.columns) == 0 {
+		return nil, errors.New("bun: AddUniqueConstraintQuery requires at least one column")
+	}
+
+	if !fmter.HasFeature(feature.AddConstraint) {
+		return nil, fmt.Errorf("bun: %s does not support ADD CONSTRAINT", fmter.Dialect().Name())
+	}
+	if q.deferrable && !fmter.HasFeature(feature.DeferrableConstraint) {
+		return nil, fmt.Errorf("bun: %s does not support DEFERRABLE constraints",
+			fmter.Dialect().Name())
+	}
+
+	b = append(b, "ALTER TABLE "...)
+	b, err = q.appendFirstTable(fmter, b)
+	if err != nil {
+		return nil, err
+	}
+
+	b = append(b, " ADD "...)
+	if !q.name.IsZero() {
+		b = append(b, "CONSTRAINT "...)
+		b, err = q.name.AppendQuery(fmter, b)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, ' ')
+	}
+
+	b = append(b, "UNIQUE ("...)
+	for i, col := range q.columns {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		b, err = col.AppendQuery(fmter, b)
+		if err != nil {
+			return nil, err
+		}
+	}
+	b = append(b, ')')
+
+	if q.deferrable {
+		b = append(b, " DEFERRABLE"...)
+		if q.initiallyDeferred {
+			b = append(b, " INITIALLY DEFERRED"...)
+		}
+	}
+
+	return b, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (q *AddUniqueConstraintQuery) Exec(ctx context.Context, dest ...interface{}) (sql.Result, error) {
+	queryBytes, err := q.AppendQuery(q.db.fmter, q.db.makeQueryBytes())
+	if err != nil {
+		return nil, err
+	}
+
+	query := internal.String(queryBytes)
+	return q.exec(ctx, q, query)
+}
@@ -1,11 +1,16 @@
 package bun
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/uptrace/bun/dialect/feature"
+	"github.com/uptrace/bun/dialect/sqltype"
 	"github.com/uptrace/bun/internal"
 	"github.com/uptrace/bun/schema"
 )
@@ -13,6 +18,9 @@ import (
 type DeleteQuery struct {
 	whereBaseQuery
 	returningQuery
+
+	joins     []joinQuery
+	deletedAt schema.QueryWithArgs
 }
 
 var _ Query = (*DeleteQuery)(nil)
@@ -21,8 +29,9 @@ func NewDeleteQuery(db *DB) *DeleteQuery {
 	q := &DeleteQuery{
 		whereBaseQuery: whereBaseQuery{
 			baseQuery: baseQuery{
-				db:   db,
-				conn: db.DB,
+				db:    db,
+				conn:  db.DB,
+				flags: db.queryFlags(),
 			},
 		},
 	}
@@ -49,6 +58,13 @@ func (q *DeleteQuery) With(name string, query schema.QueryAppender) *DeleteQuery
 	return q
 }
 
+// Only adds the ONLY keyword before the table name so PostgreSQL does not
+// include rows from tables that inherit from it.
+func (q *DeleteQuery) Only() *DeleteQuery {
+	q.flags = q.flags.Set(onlyFlag)
+	return q
+}
+
 func (q *DeleteQuery) Table(tables ...string) *DeleteQuery {
 	for _, table := range tables {
 		q.addTable(schema.UnsafeIdent(table))
@@ -66,6 +82,46 @@ func (q *DeleteQuery) ModelTableExpr(query string, args ...interface{}) *DeleteQ
 	return q
 }
 
+// Schema qualifies unqualified table names with the schema.
+// It overrides the schema set with DB.WithSchema.
+func (q *DeleteQuery) Schema(name string) *DeleteQuery {
+	q.schemaName = name
+	return q
+}
+
+// Join adds a JOIN to a multi-table delete, for example,
+// `DELETE model FROM models AS model JOIN stories ON ...`.
+// Only MySQL supports it; use Table and Where on PostgreSQL,
+// which render a USING clause.
+func (q *DeleteQuery) Join(join string, args ...interface{}) *DeleteQuery {
+	if !q.hasFeature(feature.UpdateMultiTable) {
+		q.setErr(fmt.Errorf("bun: %s does not support JOIN in DELETE, use Table instead", q.db.Dialect().Name()))
+		return q
+	}
+	q.joins = append(q.joins, joinQuery{
+		join: schema.SafeQuery(join, args),
+	})
+	return q
+}
+
+func (q *DeleteQuery) JoinOn(cond string, args ...interface{}) *DeleteQuery {
+	return q.joinOn(cond, args, " AND ")
+}
+
+func (q *DeleteQuery) JoinOnOr(cond string, args ...interface{}) *DeleteQuery {
+	return q.joinOn(cond, args, " OR ")
+}
+
+func (q *DeleteQuery) joinOn(cond string, args []interface{}, sep string) *DeleteQuery {
+	if len(q.joins) == 0 {
+		q.setErr(errors.New("bun: query has no joins"))
+		return q
+	}
+	j := &q.joins[len(q.joins)-1]
+	j.on = append(j.on, schema.SafeQueryWithSep(cond, args, sep))
+	return q
+}
+
 //------------------------------------------------------------------------------
 
 func (q *DeleteQuery) WherePK(cols ...string) *DeleteQuery {
@@ -73,6 +129,14 @@ func (q *DeleteQuery) WherePK(cols ...string) *DeleteQuery {
 	return q
 }
 
+// WherePKSorted is like WherePK, but sorts primary key values of a slice-based
+// model so the same set of rows always produces the same query.
+func (q *DeleteQuery) WherePKSorted(cols ...string) *DeleteQuery {
+	q.flags = q.flags.Set(wherePKSortedFlag)
+	q.addWhereCols(cols)
+	return q
+}
+
 func (q *DeleteQuery) Where(query string, args ...interface{}) *DeleteQuery {
 	q.addWhere(schema.SafeQueryWithSep(query, args, " AND "))
 	return q
@@ -83,6 +147,22 @@ func (q *DeleteQuery) WhereOr(query string, args ...interface{}) *DeleteQuery {
 	return q
 }
 
+// WhereIf adds a WHERE condition joined with AND only when cond is true.
+func (q *DeleteQuery) WhereIf(cond bool, query string, args ...interface{}) *DeleteQuery {
+	if cond {
+		q.addWhere(schema.SafeQueryWithSep(query, args, " AND "))
+	}
+	return q
+}
+
+// WhereOrIf adds a WHERE condition joined with OR only when cond is true.
+func (q *DeleteQuery) WhereOrIf(cond bool, query string, args ...interface{}) *DeleteQuery {
+	if cond {
+		q.addWhere(schema.SafeQueryWithSep(query, args, " OR "))
+	}
+	return q
+}
+
 func (q *DeleteQuery) WhereGroup(sep string, fn func(*DeleteQuery) *DeleteQuery) *DeleteQuery {
 	saved := q.where
 	q.where = nil
@@ -97,6 +177,110 @@ func (q *DeleteQuery) WhereGroup(sep string, fn func(*DeleteQuery) *DeleteQuery)
 	return q
 }
 
+// WhereOrQuery appends WHERE conditions of the other query as a parenthesized
+// group that is joined with OR, for example, WHERE (a) OR ((b) AND (c)).
+func (q *DeleteQuery) WhereOrQuery(other *DeleteQuery) *DeleteQuery {
+	q.addWhereQuery(" OR ", &other.whereBaseQuery)
+	return q
+}
+
+// WhereSubAny adds a condition comparing the column with any row
+// of the subquery, for example, WHERE ("price" = ANY (SELECT ...)).
+// SQLite does not support ANY with a subquery.
+func (q *DeleteQuery) WhereSubAny(column, op string, query schema.QueryAppender) *DeleteQuery {
+	q.addWhereSub(column, op, "ANY", query)
+	return q
+}
+
+// WhereSubAll adds a condition comparing the column with all rows
+// of the subquery, for example, WHERE ("price" > ALL (SELECT ...)).
+// SQLite does not support ALL with a subquery.
+func (q *DeleteQuery) WhereSubAll(column, op string, query schema.QueryAppender) *DeleteQuery {
+	q.addWhereSub(column, op, "ALL", query)
+	return q
+}
+
+// WhereInFunc adds a `column IN (...)` condition with values pulled from next
+// until it returns false. The number of values is limited to 10000.
+func (q *DeleteQuery) WhereInFunc(column string, next func() (interface{}, bool)) *DeleteQuery {
+	q.addWhereInFunc(column, next)
+	return q
+}
+
+// WhereInValuesJoin adds a `column IN (...)` condition that PostgreSQL renders
+// as a VALUES constructor, for example, `id IN (VALUES (1), (2), (3))`.
+// PostgreSQL plans it as a hash semi-join, which is usually faster than an IN
+// list with thousands of values; see BenchmarkWhereInValuesJoin. Dialects
+// without feature.InValues use a regular IN list.
+func (q *DeleteQuery) WhereInValuesJoin(column string, values interface{}) *DeleteQuery {
+	q.addWhereInValuesJoin(column, values)
+	return q
+}
+
+// WhereRaw adds the SQL condition verbatim without processing placeholders,
+// which allows operators such as the PostgreSQL jsonb `?` operator:
+//
+//    q.WhereRaw(`attrs ? 'color'`)
+//
+// The condition is not escaped. Never pass user input to WhereRaw.
+func (q *DeleteQuery) WhereRaw(query string) *DeleteQuery {
+	q.addWhereRaw(query)
+	return q
+}
+
+// WhereInChunked adds a `column IN (...)` condition that is split into groups
+// of at most 1000 values joined with OR, for example,
+// `("id" IN (1, ...) OR "id" IN (1001, ...))`. It is meant for databases that
+// limit the number of items in one IN list, for example, Oracle allows 1000.
+// The values are inlined like with In, so it does not reduce the number of
+// query parameters.
+func (q *DeleteQuery) WhereInChunked(column string, values interface{}) *DeleteQuery {
+	q.addWhereInChunked(column, values)
+	return q
+}
+
+// WhereSTDWithin adds a PostGIS condition that matches rows within the distance
+// in meters from the point, for example:
+//
+//    q.WhereSTDWithin("location", -73.9857, 40.7484, 500)
+//
+// The coordinates are longitude and latitude in WGS 84 (SRID 4326), and the
+// column is cast to geography. Only PostgreSQL with PostGIS supports it.
+func (q *DeleteQuery) WhereSTDWithin(column string, lon, lat, meters float64) *DeleteQuery {
+	q.addWhereSTDWithin(column, lon, lat, meters)
+	return q
+}
+
+// WhereTrigramSimilar adds a pg_trgm fuzzy match condition, for example,
+// `"name" % 'jon' AND similarity("name", 'jon') >= 0.5`. The % operator
+// can use a trigram index and matches rows above pg_trgm.similarity_threshold
+// (0.3 by default), so thresholds below it have no effect. Use 0 to rely on
+// the % operator only. Only PostgreSQL with pg_trgm supports it.
+func (q *DeleteQuery) WhereTrigramSimilar(column, query string, threshold float64) *DeleteQuery {
+	q.addWhereTrigramSimilar(column, query, threshold)
+	return q
+}
+
+// WhereCollate adds a comparison that uses the collation, for example,
+// WHERE ("name" = 'foo' COLLATE "C") on PostgreSQL.
+func (q *DeleteQuery) WhereCollate(column, op string, value interface{}, collation string) *DeleteQuery {
+	q.addWhereCollate(column, op, value, collation)
+	return q
+}
+
+// Conditions returns a copy of the WHERE conditions added so far.
+// Groups are represented by items with "(" and ")" separators.
+func (q *DeleteQuery) Conditions() []schema.QueryWithSep {
+	return q.conditions()
+}
+
+// SetConditions replaces the WHERE conditions, for example,
+// with conditions returned by Conditions and then reordered.
+func (q *DeleteQuery) SetConditions(where []schema.QueryWithSep) *DeleteQuery {
+	q.setConditions(where)
+	return q
+}
+
 func (q *DeleteQuery) WhereDeleted() *DeleteQuery {
 	q.whereDeleted()
 	return q
@@ -112,6 +296,21 @@ func (q *DeleteQuery) ForceDelete() *DeleteQuery {
 	return q
 }
 
+// SetDeletedAt overrides the value assigned to the soft delete column.
+// Timestamp columns default to the current time of the database in UTC,
+// for example, CURRENT_TIMESTAMP or UTC_TIMESTAMP() on MySQL. To use the Go
+// clock instead:
+//
+//    db.NewDelete().Model(&user).WherePK().SetDeletedAt("?", time.Now())
+//
+// The model field is still set to time.Now(), which may differ from the value
+// computed by the database. Use Returning("deleted_at") to scan the stored
+// value back on dialects that support RETURNING.
+func (q *DeleteQuery) SetDeletedAt(query string, args ...interface{}) *DeleteQuery {
+	q.deletedAt = schema.SafeQuery(query, args)
+	return q
+}
+
 //------------------------------------------------------------------------------
 
 // Returning adds a RETURNING clause to the query.
@@ -122,6 +321,17 @@ func (q *DeleteQuery) Returning(query string, args ...interface{}) *DeleteQuery
 	return q
 }
 
+// ReturningAll returns all model columns, for example,
+// to scan deleted rows into a slice for auditing.
+func (q *DeleteQuery) ReturningAll() *DeleteQuery {
+	if q.table == nil {
+		q.setErr(fmt.Errorf("bun: got %T, but ReturningAll requires a struct or slice-based model", q.model))
+		return q
+	}
+	q.addReturningAll(q.table)
+	return q
+}
+
 func (q *DeleteQuery) hasReturning() bool {
 	if !q.db.features.Has(feature.Returning) {
 		return false
@@ -140,9 +350,13 @@ func (q *DeleteQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte, e
 		return nil, q.err
 	}
 
-	fmter = formatterWithModel(fmter, q)
+	fmter = formatterWithModel(fmter, q.db, q)
 
 	if q.isSoftDelete() {
+		if len(q.joins) > 0 {
+			return nil, errors.New("bun: soft delete does not support Join, use ForceDelete")
+		}
+
 		now := time.Now()
 
 		if err := q.tableModel.updateSoftDeleteField(now); err != nil {
@@ -153,36 +367,65 @@ func (q *DeleteQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte, e
 			whereBaseQuery: q.whereBaseQuery,
 			returningQuery: q.returningQuery,
 		}
-		upd.Set(q.softDeleteSet(fmter, now))
+		set, err := q.softDeleteSet(fmter, now)
+		if err != nil {
+			return nil, err
+		}
+		upd.Set(set)
 
 		return upd.AppendQuery(fmter, b)
 	}
 
 	q = q.WhereDeleted()
-	withAlias := q.db.features.Has(feature.DeleteTableAlias)
+	// MySQL does not support USING and uses `DELETE t FROM t, other` or
+	// `DELETE t FROM t JOIN other ON ...` instead.
+	multiTable := (q.hasMultiTables() || len(q.joins) > 0) &&
+		fmter.HasFeature(feature.UpdateMultiTable)
+	withAlias := q.db.features.Has(feature.DeleteTableAlias) || multiTable
 
 	b, err = q.appendWith(fmter, b)
 	if err != nil {
 		return nil, err
 	}
 
-	b = append(b, "DELETE FROM "...)
+	if multiTable {
+		b = append(b, "DELETE "...)
+		b, err = q.appendDeleteTarget(fmter, b)
+		if err != nil {
+			return nil, err
+		}
 
-	if withAlias {
-		b, err = q.appendFirstTableWithAlias(fmter, b)
+		b = append(b, " FROM "...)
+		b, err = q.appendTablesWithAlias(fmter, b)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, j := range q.joins {
+			b, err = j.AppendQuery(fmter, b)
+			if err != nil {
+				return nil, err
+			}
+		}
 	} else {
-		b, err = q.appendFirstTable(fmter, b)
-	}
-	if err != nil {
-		return nil, err
-	}
+		b = append(b, "DELETE FROM "...)
 
-	if q.hasMultiTables() {
-		b = append(b, " USING "...)
-		b, err = q.appendOtherTables(fmter, b)
+		if withAlias {
+			b, err = q.appendFirstTableWithAlias(fmter, b)
+		} else {
+			b, err = q.appendFirstTable(fmter, b)
+		}
 		if err != nil {
 			return nil, err
 		}
+
+		if q.hasMultiTables() {
+			b = append(b, " USING "...)
+			b, err = q.appendOtherTables(fmter, b)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	b, err = q.mustAppendWhere(fmter, b, withAlias)
@@ -201,20 +444,119 @@ func (q *DeleteQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte, e
 	return b, nil
 }
 
+// AppendQueryArgs is like AppendQuery, but appends placeholders instead of
+// query arguments and returns the arguments separately, for example,
+// to reuse a prepared statement.
+func (q *DeleteQuery) AppendQueryArgs(fmter schema.Formatter, b []byte) ([]byte, []interface{}, error) {
+	return appendQueryArgs(fmter, b, q)
+}
+
+// appendDeleteTarget appends the table that MySQL deletes rows from, which
+// must match a table in the FROM list. Tables with an alias are referenced
+// by the alias.
+func (q *DeleteQuery) appendDeleteTarget(fmter schema.Formatter, b []byte) ([]byte, error) {
+	if q.table != nil {
+		return append(b, q.table.SQLAlias...), nil
+	}
+	if len(q.tables) == 0 {
+		return nil, errors.New("bun: query does not have a table")
+	}
+
+	table := q.tables[0]
+	if table.Args == nil {
+		return q.appendTable(fmter, b, table)
+	}
+
+	expr, err := table.AppendQuery(fmter, nil)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, tableExprAlias(expr)...), nil
+}
+
+// tableExprAlias returns the alias of a table expression such as
+// `models AS m` or `models m`, or the expression itself if it has no alias.
+func tableExprAlias(expr []byte) []byte {
+	expr = bytes.TrimSpace(expr)
+	if len(expr) == 0 {
+		return expr
+	}
+
+	var start int
+	switch c := expr[len(expr)-1]; c {
+	case '"', '`', ']':
+		open := c
+		if c == ']' {
+			open = '['
+		}
+		start = bytes.LastIndexByte(expr[:len(expr)-1], open)
+		if start == -1 {
+			return expr
+		}
+	default:
+		start = bytes.LastIndexAny(expr, " \t\r\n") + 1
+	}
+
+	if start == 0 || expr[start-1] == '.' {
+		return expr
+	}
+	return expr[start:]
+}
+
 func (q *DeleteQuery) isSoftDelete() bool {
 	return q.tableModel != nil && q.table.SoftDeleteField != nil && !q.flags.Has(forceDeleteFlag)
 }
 
-func (q *DeleteQuery) softDeleteSet(fmter schema.Formatter, tm time.Time) string {
+// softDeleteSet returns the SET clause of a soft delete. Timestamp columns are
+// set to the current time in UTC and other columns to tm, which is also assigned
+// to the model field, unless SetDeletedAt is used.
+func (q *DeleteQuery) softDeleteSet(fmter schema.Formatter, tm time.Time) (string, error) {
+	field := q.table.SoftDeleteField
+
 	b := make([]byte, 0, 32)
 	if fmter.HasFeature(feature.UpdateMultiTable) {
 		b = append(b, q.table.SQLAlias...)
 		b = append(b, '.')
 	}
-	b = append(b, q.table.SoftDeleteField.SQLName...)
+	b = append(b, field.SQLName...)
 	b = append(b, " = "...)
-	b = schema.Append(fmter, b, tm)
-	return internal.String(b)
+
+	switch {
+	case !q.deletedAt.IsZero():
+		var err error
+		b, err = q.deletedAt.AppendQuery(fmter, b)
+		if err != nil {
+			return "", err
+		}
+	case q.table.SoftDeleteBool && !fmter.HasFeature(feature.BoolType):
+		b = append(b, '1')
+	case q.table.SoftDeleteBool:
+		b = schema.Append(fmter, b, true)
+	case field.IsUnixTime():
+		b = field.Append(fmter, b, unixTimeValue(field, tm))
+	case schema.DiscoverSQLType(field.IndirectType) == sqltype.Timestamp:
+		b = appendCurrentTimestamp(fmter, b)
+	default:
+		b = schema.Append(fmter, b, tm)
+	}
+	return internal.String(b), nil
+}
+
+// appendCurrentTimestamp appends the current time in UTC, which is how
+// time.Time values are stored.
+func appendCurrentTimestamp(fmter schema.Formatter, b []byte) []byte {
+	if d, ok := fmter.Dialect().(schema.CurrentTimestampAppender); ok {
+		return d.AppendCurrentTimestamp(b)
+	}
+	return append(b, "CURRENT_TIMESTAMP"...)
+}
+
+// unixTimeValue returns tm in the form expected by the appender of the field.
+func unixTimeValue(field *schema.Field, tm time.Time) reflect.Value {
+	if field.IsPtr {
+		return reflect.ValueOf(&tm)
+	}
+	return reflect.ValueOf(tm)
 }
 
 //------------------------------------------------------------------------------
@@ -296,6 +638,12 @@ func (q *DeleteQuery) String() string {
 	return string(buf)
 }
 
+// PrettyString is like String, but starts every top-level clause on a new line.
+// It is meant for logging and tests and does not change the executed query.
+func (q *DeleteQuery) PrettyString() string {
+	return prettyQuery(q.String())
+}
+
 //------------------------------------------------------------------------------
 
 func (q *DeleteQuery) QueryBuilder() QueryBuilder {
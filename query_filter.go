@@ -0,0 +1,73 @@
+package bun
+
+import (
+	"fmt"
+
+	"github.com/uptrace/bun/schema"
+)
+
+// Filter accumulates WHERE conditions independently of a query so the same
+// conditions can be applied to select, update, and delete queries:
+//
+//    f := bun.NewFilter().Where("tenant_id = ?", tenantID).WhereOr("public")
+//    f.ApplyTo(db.NewSelect().Model(&books).QueryBuilder())
+//    f.ApplyTo(db.NewDelete().Model((*Book)(nil)).QueryBuilder())
+type Filter struct {
+	q whereBaseQuery
+}
+
+func NewFilter() *Filter {
+	return new(Filter)
+}
+
+func (f *Filter) Where(query string, args ...interface{}) *Filter {
+	f.q.addWhere(schema.SafeQueryWithSep(query, args, " AND "))
+	return f
+}
+
+func (f *Filter) WhereOr(query string, args ...interface{}) *Filter {
+	f.q.addWhere(schema.SafeQueryWithSep(query, args, " OR "))
+	return f
+}
+
+func (f *Filter) WhereGroup(sep string, fn func(*Filter) *Filter) *Filter {
+	saved := f.q.where
+	f.q.where = nil
+
+	f = fn(f)
+
+	where := f.q.where
+	f.q.where = saved
+
+	f.q.addWhereGroup(sep, where)
+
+	return f
+}
+
+// Conditions returns a copy of the conditions added so far.
+func (f *Filter) Conditions() []schema.QueryWithSep {
+	return f.q.conditions()
+}
+
+// ApplyTo appends the filter conditions to the query as a parenthesized group
+// that is joined with AND.
+func (f *Filter) ApplyTo(qb QueryBuilder) QueryBuilder {
+	var q *whereBaseQuery
+	switch v := qb.Unwrap().(type) {
+	case *SelectQuery:
+		q = &v.whereBaseQuery
+	case *UpdateQuery:
+		q = &v.whereBaseQuery
+	case *DeleteQuery:
+		q = &v.whereBaseQuery
+	default:
+		panic(fmt.Errorf("bun: Filter does not support %T", v))
+	}
+
+	if f.q.err != nil {
+		q.setErr(f.q.err)
+		return qb
+	}
+	q.addWhereGroup(" AND ", f.q.conditions())
+	return qb
+}
@@ -0,0 +1,94 @@
+package bun
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/uptrace/bun/dialect/feature"
+	"github.com/uptrace/bun/schema"
+)
+
+// FuncExpr builds a set-returning function call that can be passed to TableExpr:
+//
+//    q.TableExpr("?", bun.Func("unnest(?)", pgdialect.Array(ids)).
+//        WithOrdinality().
+//        As("t", "val", "idx"))
+//
+// WITH ORDINALITY is supported by PostgreSQL.
+type FuncExpr struct {
+	fn         schema.QueryWithArgs
+	ordinality bool
+	alias      string
+	columns    []string
+}
+
+var (
+	_ schema.QueryAppender = (*FuncExpr)(nil)
+	_ dialectChecker       = (*FuncExpr)(nil)
+)
+
+func Func(query string, args ...interface{}) *FuncExpr {
+	return &FuncExpr{
+		fn: schema.SafeQuery(query, args),
+	}
+}
+
+// WithOrdinality adds a bigint column that numbers the returned rows
+// starting from 1. The column is the last one in the alias column list.
+func (f *FuncExpr) WithOrdinality() *FuncExpr {
+	f.ordinality = true
+	return f
+}
+
+// As sets the table alias and optionally the names of the returned columns.
+func (f *FuncExpr) As(alias string, columns ...string) *FuncExpr {
+	f.alias = alias
+	f.columns = columns
+	return f
+}
+
+func (f *FuncExpr) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte, err error) {
+	if f.fn.Query == "" {
+		return nil, errors.New("bun: Func requires a function call")
+	}
+	if len(f.columns) > 0 && f.alias == "" {
+		return nil, errors.New("bun: Func column list requires an alias")
+	}
+
+	b, err = f.fn.AppendQuery(fmter, b)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.ordinality {
+		if err := f.checkDialect(fmter.Dialect()); err != nil {
+			return nil, err
+		}
+		b = append(b, " WITH ORDINALITY"...)
+	}
+
+	if f.alias != "" {
+		b = append(b, " AS "...)
+		b = fmter.AppendIdent(b, f.alias)
+
+		if len(f.columns) > 0 {
+			b = append(b, " ("...)
+			for i, col := range f.columns {
+				if i > 0 {
+					b = append(b, ", "...)
+				}
+				b = fmter.AppendIdent(b, col)
+			}
+			b = append(b, ')')
+		}
+	}
+
+	return b, nil
+}
+
+func (f *FuncExpr) checkDialect(d schema.Dialect) error {
+	if f.ordinality && !d.Features().Has(feature.WithOrdinality) {
+		return fmt.Errorf("bun: %s does not support WITH ORDINALITY", d.Name())
+	}
+	return nil
+}
@@ -3,7 +3,13 @@ package bun
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
 
+	"github.com/uptrace/bun/dialect/feature"
 	"github.com/uptrace/bun/internal"
 	"github.com/uptrace/bun/schema"
 )
@@ -16,10 +22,16 @@ type CreateIndexQuery struct {
 	spatial      bool
 	concurrently bool
 	ifNotExists  bool
+	doBlock      bool
+	newConn      bool
 
 	index   schema.QueryWithArgs
 	using   schema.QueryWithArgs
 	include []schema.QueryWithArgs
+
+	fillFactor    int
+	storageParams []schema.QueryWithArgs
+	comment       string
 }
 
 var _ Query = (*CreateIndexQuery)(nil)
@@ -46,6 +58,9 @@ func (q *CreateIndexQuery) Model(model interface{}) *CreateIndexQuery {
 	return q
 }
 
+// Unique creates a unique index. Unique indexes are checked immediately,
+// so use NewAddUniqueConstraint with Deferrable when the check must be
+// deferred until the end of the transaction.
 func (q *CreateIndexQuery) Unique() *CreateIndexQuery {
 	q.unique = true
 	return q
@@ -61,6 +76,22 @@ func (q *CreateIndexQuery) IfNotExists() *CreateIndexQuery {
 	return q
 }
 
+// IfNotExistsDoBlock wraps the statement in a PostgreSQL DO block that ignores
+// errors about already existing objects. Unlike IfNotExists, it works with
+// PostgreSQL versions that don't support CREATE INDEX IF NOT EXISTS.
+func (q *CreateIndexQuery) IfNotExistsDoBlock() *CreateIndexQuery {
+	q.doBlock = true
+	return q
+}
+
+// WithNewConn executes the query using a new connection from the *DB pool
+// instead of the current connection or transaction, for example,
+// to create an index CONCURRENTLY while a transaction is in progress.
+func (q *CreateIndexQuery) WithNewConn() *CreateIndexQuery {
+	q.newConn = true
+	return q
+}
+
 //------------------------------------------------------------------------------
 
 func (q *CreateIndexQuery) Index(query string) *CreateIndexQuery {
@@ -132,6 +163,48 @@ func (q *CreateIndexQuery) IncludeExpr(query string, args ...interface{}) *Creat
 
 //------------------------------------------------------------------------------
 
+// FillFactor sets the percentage from 10 to 100 to which the index pages
+// are filled. Only PostgreSQL supports it.
+func (q *CreateIndexQuery) FillFactor(pct int) *CreateIndexQuery {
+	if pct < 10 || pct > 100 {
+		q.setErr(fmt.Errorf("bun: FillFactor must be between 10 and 100, got %d", pct))
+		return q
+	}
+	q.fillFactor = pct
+	return q
+}
+
+// StorageParam adds an index storage parameter that is rendered in the WITH
+// clause together with FillFactor, for example:
+//
+//    db.NewCreateIndex().Model(book).Column("title").StorageParam("deduplicate_items = off")
+//
+// Only PostgreSQL supports it.
+func (q *CreateIndexQuery) StorageParam(query string, args ...interface{}) *CreateIndexQuery {
+	q.storageParams = append(q.storageParams, schema.SafeQuery(query, args))
+	return q
+}
+
+// Comment sets the index comment. MySQL renders it with the COMMENT index option.
+// PostgreSQL can't set a comment in CREATE INDEX, so Exec runs a separate
+// COMMENT ON INDEX statement after the index is created. Use CommentQuery
+// to get that statement, for example, to write it to a migration file.
+func (q *CreateIndexQuery) Comment(text string) *CreateIndexQuery {
+	q.comment = text
+	return q
+}
+
+// CommentQuery returns the COMMENT ON INDEX statement for the comment set
+// with Comment or nil if the dialect renders the comment in CREATE INDEX.
+func (q *CreateIndexQuery) CommentQuery() schema.QueryAppender {
+	if q.comment == "" || q.db.HasFeature(feature.InlineIndexComment) {
+		return nil
+	}
+	return indexCommentQuery{q}
+}
+
+//------------------------------------------------------------------------------
+
 func (q *CreateIndexQuery) Where(query string, args ...interface{}) *CreateIndexQuery {
 	q.addWhere(schema.SafeQueryWithSep(query, args, " AND "))
 	return q
@@ -142,6 +215,22 @@ func (q *CreateIndexQuery) WhereOr(query string, args ...interface{}) *CreateInd
 	return q
 }
 
+func (q *CreateIndexQuery) WhereGroup(
+	sep string, fn func(*CreateIndexQuery) *CreateIndexQuery,
+) *CreateIndexQuery {
+	saved := q.where
+	q.where = nil
+
+	q = fn(q)
+
+	where := q.where
+	q.where = saved
+
+	q.addWhereGroup(sep, where)
+
+	return q
+}
+
 //------------------------------------------------------------------------------
 
 func (q *CreateIndexQuery) Operation() string {
@@ -153,6 +242,17 @@ func (q *CreateIndexQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []by
 		return nil, q.err
 	}
 
+	if !q.doBlock {
+		return q.appendQuery(fmter, b)
+	}
+
+	if q.concurrently {
+		return nil, errors.New("bun: IfNotExistsDoBlock can't be used with Concurrently")
+	}
+	return appendIfNotExistsDoBlock(fmter, b, q.appendQuery)
+}
+
+func (q *CreateIndexQuery) appendQuery(fmter schema.Formatter, b []byte) (_ []byte, err error) {
 	b = append(b, "CREATE "...)
 
 	if q.unique {
@@ -174,9 +274,17 @@ func (q *CreateIndexQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []by
 		b = append(b, "IF NOT EXISTS "...)
 	}
 
-	b, err = q.index.AppendQuery(fmter, b)
-	if err != nil {
-		return nil, err
+	if q.index.IsZero() {
+		name, err := q.indexName(fmter)
+		if err != nil {
+			return nil, err
+		}
+		b = fmter.AppendIdent(b, name)
+	} else {
+		b, err = q.index.AppendQuery(fmter, b)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	b = append(b, " ON "...)
@@ -219,6 +327,11 @@ func (q *CreateIndexQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []by
 		b = append(b, ')')
 	}
 
+	b, err = q.appendStorageParams(fmter, b)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(q.where) > 0 {
 		b = append(b, " WHERE "...)
 		b, err = appendWhere(fmter, b, q.where)
@@ -227,9 +340,125 @@ func (q *CreateIndexQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []by
 		}
 	}
 
+	if q.comment != "" {
+		switch {
+		case fmter.HasFeature(feature.InlineIndexComment):
+			b = append(b, " COMMENT "...)
+			b = fmter.Dialect().AppendString(b, q.comment)
+		case fmter.HasFeature(feature.IndexComment):
+			// See indexCommentQuery.
+		default:
+			return nil, fmt.Errorf("bun: %s does not support index comments", fmter.Dialect().Name())
+		}
+	}
+
+	return b, nil
+}
+
+func (q *CreateIndexQuery) appendStorageParams(
+	fmter schema.Formatter, b []byte,
+) (_ []byte, err error) {
+	if q.fillFactor == 0 && len(q.storageParams) == 0 {
+		return b, nil
+	}
+	if !fmter.HasFeature(feature.IndexStorageParams) {
+		return nil, fmt.Errorf("bun: %s does not support index storage parameters",
+			fmter.Dialect().Name())
+	}
+
+	b = append(b, " WITH ("...)
+	if q.fillFactor != 0 {
+		b = append(b, "fillfactor = "...)
+		b = strconv.AppendInt(b, int64(q.fillFactor), 10)
+	}
+	for i, param := range q.storageParams {
+		if i > 0 || q.fillFactor != 0 {
+			b = append(b, ", "...)
+		}
+		b, err = param.AppendQuery(fmter, b)
+		if err != nil {
+			return nil, err
+		}
+	}
+	b = append(b, ')')
+
 	return b, nil
 }
 
+// IndexName returns the name set with Index or the name that is generated
+// when Index is not called. It returns an empty string for IndexExpr
+// or when the name can't be generated.
+func (q *CreateIndexQuery) IndexName() string {
+	if !q.index.IsZero() {
+		if q.index.Args == nil {
+			return q.index.Query
+		}
+		return ""
+	}
+	name, err := q.indexName(q.db.fmter)
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+// indexName generates an index name like idx_table_col1_col2 when Index
+// is not called. If the dialect implements schema.IdentLimiter, names that
+// exceed the limit are truncated and suffixed with a hash of the full name
+// to avoid collisions.
+func (q *CreateIndexQuery) indexName(fmter schema.Formatter) (string, error) {
+	var table string
+	switch {
+	case q.table != nil && q.modelTableName.IsZero():
+		table = q.table.Name
+	case len(q.tables) > 0 && q.tables[0].Args == nil:
+		table = q.tables[0].Query
+	default:
+		return "", errors.New("bun: CreateIndexQuery requires Index")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("idx_")
+	sb.WriteString(table)
+	for _, col := range q.columns {
+		sb.WriteByte('_')
+		if col.Args == nil {
+			sb.WriteString(col.Query)
+		} else {
+			sb.WriteString("expr")
+		}
+	}
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, sb.String())
+
+	limiter, ok := fmter.Dialect().(schema.IdentLimiter)
+	if !ok {
+		return name, nil
+	}
+	maxLen := limiter.MaxIdentLen()
+	if len(name) <= maxLen {
+		return name, nil
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	suffix := fmt.Sprintf("_%08x", h.Sum32())
+	return name[:maxLen-len(suffix)] + suffix, nil
+}
+
+// AppendQueryArgs is like AppendQuery, but appends placeholders instead of
+// query arguments and returns the arguments separately, for example,
+// to reuse a prepared statement.
+func (q *CreateIndexQuery) AppendQueryArgs(fmter schema.Formatter, b []byte) ([]byte, []interface{}, error) {
+	return appendQueryArgs(fmter, b, q)
+}
+
 //------------------------------------------------------------------------------
 
 func (q *CreateIndexQuery) Exec(ctx context.Context, dest ...interface{}) (sql.Result, error) {
@@ -240,10 +469,71 @@ func (q *CreateIndexQuery) Exec(ctx context.Context, dest ...interface{}) (sql.R
 
 	query := internal.String(queryBytes)
 
+	if q.newConn {
+		conn, err := q.db.DB.Conn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+
+		prevConn := q.conn
+		q.conn = conn
+		defer func() {
+			q.conn = prevConn
+		}()
+	}
+
 	res, err := q.exec(ctx, q, query)
 	if err != nil {
 		return nil, err
 	}
 
+	if comment := q.CommentQuery(); comment != nil {
+		queryBytes, err := comment.AppendQuery(q.db.fmter, q.db.makeQueryBytes())
+		if err != nil {
+			return nil, err
+		}
+		if _, err := q.exec(ctx, q, internal.String(queryBytes)); err != nil {
+			return nil, err
+		}
+	}
+
 	return res, nil
 }
+
+//------------------------------------------------------------------------------
+
+// indexCommentQuery renders the PostgreSQL COMMENT ON INDEX statement.
+type indexCommentQuery struct {
+	q *CreateIndexQuery
+}
+
+var _ schema.QueryAppender = indexCommentQuery{}
+
+func (c indexCommentQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte, err error) {
+	q := c.q
+	if q.err != nil {
+		return nil, q.err
+	}
+	if !fmter.HasFeature(feature.IndexComment) {
+		return nil, fmt.Errorf("bun: %s does not support COMMENT ON INDEX", fmter.Dialect().Name())
+	}
+
+	b = append(b, "COMMENT ON INDEX "...)
+	if q.index.IsZero() {
+		name, err := q.indexName(fmter)
+		if err != nil {
+			return nil, err
+		}
+		b = fmter.AppendIdent(b, name)
+	} else {
+		b, err = q.index.AppendQuery(fmter, b)
+		if err != nil {
+			return nil, err
+		}
+	}
+	b = append(b, " IS "...)
+	b = fmter.Dialect().AppendString(b, q.comment)
+
+	return b, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 
+	"github.com/uptrace/bun/dialect/feature"
 	"github.com/uptrace/bun/internal"
 	"github.com/uptrace/bun/schema"
 )
@@ -35,6 +36,9 @@ func (q *DropIndexQuery) Conn(db IConn) *DropIndexQuery {
 	return q
 }
 
+// Model sets the table of the index. Dialects that require
+// DROP INDEX name ON table, for example, MySQL and MSSQL, use it
+// to render the ON clause. Other dialects ignore the table.
 func (q *DropIndexQuery) Model(model interface{}) *DropIndexQuery {
 	q.setTableModel(model)
 	return q
@@ -42,6 +46,25 @@ func (q *DropIndexQuery) Model(model interface{}) *DropIndexQuery {
 
 //------------------------------------------------------------------------------
 
+func (q *DropIndexQuery) Table(tables ...string) *DropIndexQuery {
+	for _, table := range tables {
+		q.addTable(schema.UnsafeIdent(table))
+	}
+	return q
+}
+
+func (q *DropIndexQuery) TableExpr(query string, args ...interface{}) *DropIndexQuery {
+	q.addTable(schema.SafeQuery(query, args))
+	return q
+}
+
+func (q *DropIndexQuery) ModelTableExpr(query string, args ...interface{}) *DropIndexQuery {
+	q.modelTableName = schema.SafeQuery(query, args)
+	return q
+}
+
+//------------------------------------------------------------------------------
+
 func (q *DropIndexQuery) Concurrently() *DropIndexQuery {
 	q.concurrently = true
 	return q
@@ -92,11 +115,24 @@ func (q *DropIndexQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte
 		return nil, err
 	}
 
+	if q.requiresTable(fmter) && q.hasTables() {
+		b = append(b, " ON "...)
+		b, err = q.appendFirstTable(fmter, b)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	b = q.appendCascade(fmter, b)
 
 	return b, nil
 }
 
+// requiresTable reports whether the dialect drops indexes per table.
+func (q *DropIndexQuery) requiresTable(fmter schema.Formatter) bool {
+	return fmter.HasFeature(feature.DropIndexOnTable)
+}
+
 //------------------------------------------------------------------------------
 
 func (q *DropIndexQuery) Exec(ctx context.Context, dest ...interface{}) (sql.Result, error) {
@@ -0,0 +1,149 @@
+package bun
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/uptrace/bun/dialect/feature"
+	"github.com/uptrace/bun/internal"
+	"github.com/uptrace/bun/schema"
+)
+
+// RenameIndexQuery renames an index. PostgreSQL uses ALTER INDEX and MySQL
+// uses ALTER TABLE ... RENAME INDEX, which requires a table or a model.
+// SQLite and MSSQL have no direct equivalent and return an error.
+type RenameIndexQuery struct {
+	baseQuery
+
+	ifExists bool
+
+	index   schema.QueryWithArgs
+	newName schema.QueryWithArgs
+}
+
+var _ Query = (*RenameIndexQuery)(nil)
+
+func NewRenameIndexQuery(db *DB) *RenameIndexQuery {
+	q := &RenameIndexQuery{
+		baseQuery: baseQuery{
+			db:   db,
+			conn: db.DB,
+		},
+	}
+	return q
+}
+
+func (q *RenameIndexQuery) Conn(db IConn) *RenameIndexQuery {
+	q.setConn(db)
+	return q
+}
+
+func (q *RenameIndexQuery) Model(model interface{}) *RenameIndexQuery {
+	q.setTableModel(model)
+	return q
+}
+
+//------------------------------------------------------------------------------
+
+func (q *RenameIndexQuery) Table(tables ...string) *RenameIndexQuery {
+	for _, table := range tables {
+		q.addTable(schema.UnsafeIdent(table))
+	}
+	return q
+}
+
+func (q *RenameIndexQuery) TableExpr(query string, args ...interface{}) *RenameIndexQuery {
+	q.addTable(schema.SafeQuery(query, args))
+	return q
+}
+
+func (q *RenameIndexQuery) ModelTableExpr(query string, args ...interface{}) *RenameIndexQuery {
+	q.modelTableName = schema.SafeQuery(query, args)
+	return q
+}
+
+//------------------------------------------------------------------------------
+
+// IfExists is only supported by PostgreSQL.
+func (q *RenameIndexQuery) IfExists() *RenameIndexQuery {
+	q.ifExists = true
+	return q
+}
+
+func (q *RenameIndexQuery) Rename(index, newName string) *RenameIndexQuery {
+	q.index = schema.UnsafeIdent(index)
+	q.newName = schema.UnsafeIdent(newName)
+	return q
+}
+
+//------------------------------------------------------------------------------
+
+func (q *RenameIndexQuery) Operation() string {
+	return "RENAME INDEX"
+}
+
+func (q *RenameIndexQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte, err error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	if q.index.IsZero() || q.newName.IsZero() {
+		return nil, fmt.Errorf("bun: RenameIndexQuery requires Rename")
+	}
+
+	switch name := fmter.Dialect().Name(); {
+	case fmter.HasFeature(feature.AlterIndexRename):
+		b = append(b, "ALTER INDEX "...)
+		if q.ifExists {
+			b = append(b, "IF EXISTS "...)
+		}
+	case fmter.HasFeature(feature.RenameIndexOnTable):
+		if !q.hasTables() {
+			return nil, fmt.Errorf("bun: RenameIndexQuery requires a table on %s", name)
+		}
+		b = append(b, "ALTER TABLE "...)
+		b, err = q.appendFirstTable(fmter, b)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, " RENAME INDEX "...)
+	default:
+		return nil, fmt.Errorf("bun: %s does not support renaming indexes", name)
+	}
+
+	b, err = q.index.AppendQuery(fmter, b)
+	if err != nil {
+		return nil, err
+	}
+
+	if fmter.HasFeature(feature.AlterIndexRename) {
+		b = append(b, " RENAME TO "...)
+	} else {
+		b = append(b, " TO "...)
+	}
+
+	b, err = q.newName.AppendQuery(fmter, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (q *RenameIndexQuery) Exec(ctx context.Context, dest ...interface{}) (sql.Result, error) {
+	queryBytes, err := q.AppendQuery(q.db.fmter, q.db.makeQueryBytes())
+	if err != nil {
+		return nil, err
+	}
+
+	query := internal.String(queryBytes)
+
+	res, err := q.exec(ctx, q, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
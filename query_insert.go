@@ -3,12 +3,14 @@ package bun
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
 
 	"github.com/uptrace/bun/dialect/feature"
 	"github.com/uptrace/bun/internal"
+	"github.com/uptrace/bun/internal/parser"
 	"github.com/uptrace/bun/schema"
 )
 
@@ -17,7 +19,13 @@ type InsertQuery struct {
 	returningQuery
 	customValueQuery
 
-	on schema.QueryWithArgs
+	// on is the ON clause without the conflict action, for example,
+	// CONFLICT (email), and onAction is the action, for example, DO UPDATE,
+	// so the OnConflictWhere predicate can be appended between them.
+	on            schema.QueryWithArgs
+	onAction      schema.QueryWithArgs
+	conflictWhere schema.QueryWithArgs
+	upsertExclude map[string]struct{}
 	setQuery
 
 	ignore  bool
@@ -77,6 +85,13 @@ func (q *InsertQuery) ModelTableExpr(query string, args ...interface{}) *InsertQ
 	return q
 }
 
+// Schema qualifies unqualified table names with the schema.
+// It overrides the schema set with DB.WithSchema.
+func (q *InsertQuery) Schema(name string) *InsertQuery {
+	q.schemaName = name
+	return q
+}
+
 //------------------------------------------------------------------------------
 
 func (q *InsertQuery) Column(columns ...string) *InsertQuery {
@@ -116,6 +131,20 @@ func (q *InsertQuery) WhereOr(query string, args ...interface{}) *InsertQuery {
 	return q
 }
 
+func (q *InsertQuery) WhereGroup(sep string, fn func(*InsertQuery) *InsertQuery) *InsertQuery {
+	saved := q.where
+	q.where = nil
+
+	q = fn(q)
+
+	where := q.where
+	q.where = saved
+
+	q.addWhereGroup(sep, where)
+
+	return q
+}
+
 //------------------------------------------------------------------------------
 
 // Returning adds a RETURNING clause to the query.
@@ -126,14 +155,56 @@ func (q *InsertQuery) Returning(query string, args ...interface{}) *InsertQuery
 	return q
 }
 
+// ReturningAll returns all model columns instead of only the auto-generated ones
+// so values set by triggers or column defaults are scanned back into the model.
+// Map models get all columns of the inserted row, for example:
+//
+//    values := map[string]interface{}{"name": "hello"}
+//    db.NewInsert().Model(&values).TableExpr("users").ReturningAll().Exec(ctx)
+func (q *InsertQuery) ReturningAll() *InsertQuery {
+	switch q.model.(type) {
+	case *mapModel, *mapSliceModel:
+		q.addReturning(schema.SafeQuery("*", nil))
+		return q
+	}
+	if q.table == nil {
+		q.setErr(fmt.Errorf("bun: got %T, but ReturningAll requires a struct, slice, or map model", q.model))
+		return q
+	}
+	q.addReturningAll(q.table)
+	return q
+}
+
+// OnConflictReturnInserted adds `(xmax = 0) AS inserted` to the RETURNING clause
+// so an upsert reports whether the row was inserted (true) or updated (false).
+// Unless Returning was called, all columns are returned as well.
+// Use a model field with the `scanonly` tag to receive the value:
+//
+//    Inserted bool `bun:",scanonly"`
+//
+// It relies on the xmax system column and returns an error on other dialects.
+func (q *InsertQuery) OnConflictReturnInserted() *InsertQuery {
+	if !q.hasFeature(feature.ReturnInserted) {
+		q.setErr(fmt.Errorf("bun: %s does not support OnConflictReturnInserted", q.db.Dialect().Name()))
+		return q
+	}
+	if len(q.returning) == 0 {
+		q.addReturning(schema.SafeQuery("*", nil))
+	}
+	q.addReturning(schema.SafeQuery("(xmax = 0) AS inserted", nil))
+	return q
+}
+
 //------------------------------------------------------------------------------
 
 // Ignore generates different queries depending on the DBMS:
 //   - On MySQL, it generates `INSERT IGNORE INTO`.
-//   - On PostgreSQL, it generates `ON CONFLICT DO NOTHING`.
+//   - On PostgreSQL and SQLite, it generates `ON CONFLICT DO NOTHING`.
+//   - On other databases, it is a no-op.
 func (q *InsertQuery) Ignore() *InsertQuery {
 	if q.db.fmter.HasFeature(feature.InsertOnConflict) {
-		return q.On("CONFLICT DO NOTHING")
+		q.setOn(schema.SafeQuery("CONFLICT", nil), schema.SafeQuery("DO NOTHING", nil))
+		return q
 	}
 	if q.db.fmter.HasFeature(feature.InsertIgnore) {
 		q.ignore = true
@@ -158,7 +229,7 @@ func (q *InsertQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte, e
 		return nil, q.err
 	}
 
-	fmter = formatterWithModel(fmter, q)
+	fmter = formatterWithModel(fmter, q.db, q)
 
 	b, err = q.appendWith(fmter, b)
 	if err != nil {
@@ -205,6 +276,13 @@ func (q *InsertQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte, e
 	return b, nil
 }
 
+// AppendQueryArgs is like AppendQuery, but appends placeholders instead of
+// query arguments and returns the arguments separately, for example,
+// to reuse a prepared statement.
+func (q *InsertQuery) AppendQueryArgs(fmter schema.Formatter, b []byte) ([]byte, []interface{}, error) {
+	return appendQueryArgs(fmter, b, q)
+}
+
 func (q *InsertQuery) appendColumnsValues(
 	fmter schema.Formatter, b []byte,
 ) (_ []byte, err error) {
@@ -424,13 +502,204 @@ func (q *InsertQuery) appendFields(
 
 //------------------------------------------------------------------------------
 
+// On adds the ON CONFLICT or ON DUPLICATE KEY clause, for example,
+// On("CONFLICT (email) DO UPDATE"). PostgreSQL and SQLite accept only one
+// conflict target per statement, so pick the unique constraint that is most
+// likely to conflict and handle others with separate statements.
+// Adding a second clause that differs from the first one is an error.
 func (q *InsertQuery) On(s string, args ...interface{}) *InsertQuery {
-	q.on = schema.SafeQuery(s, args)
+	q.setOn(splitConflictAction(s, args))
+	return q
+}
+
+func (q *InsertQuery) setOn(on, action schema.QueryWithArgs) {
+	if !q.on.IsZero() && (!reflect.DeepEqual(q.on, on) || !reflect.DeepEqual(q.onAction, action)) {
+		q.setErr(fmt.Errorf(
+			"bun: InsertQuery supports only one ON clause, got %q and %q",
+			onClauseString(q.on, q.onAction), onClauseString(on, action)))
+		return
+	}
+	q.on = on
+	q.onAction = action
+}
+
+// splitConflictAction splits ON CONFLICT clauses passed to On into the conflict
+// target and the DO action. The action gets the arguments that are left after
+// the placeholders in the conflict target.
+func splitConflictAction(query string, args []interface{}) (on, action schema.QueryWithArgs) {
+	on = schema.SafeQuery(query, args)
+	if len(query) < len("CONFLICT") || !strings.EqualFold(query[:len("CONFLICT")], "CONFLICT") {
+		return on, schema.QueryWithArgs{}
+	}
+
+	idx := indexConflictAction(query)
+	if idx == -1 {
+		return on, schema.QueryWithArgs{}
+	}
+
+	on.Query = strings.TrimRight(query[:idx], " \t\r\n")
+	action = schema.QueryWithArgs{
+		Query: query[idx:],
+		Args:  make([]interface{}, 0),
+	}
+	if n := countPositionalArgs(on.Query); n < len(args) {
+		action.Args = args[n:]
+	}
+	return on, action
+}
+
+// indexConflictAction returns the index of the DO keyword that is not
+// inside a string literal or a quoted identifier.
+func indexConflictAction(s string) int {
+	for i := 0; i < len(s); i++ {
+		if end := skipQuoted(s, i); end != i {
+			i = end - 1
+			continue
+		}
+		if i > 0 && isSpace(s[i-1]) &&
+			len(s) >= i+2 && strings.EqualFold(s[i:i+2], "DO") &&
+			(len(s) == i+2 || isSpace(s[i+2])) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// countPositionalArgs returns the number of ? placeholders the same way
+// the formatter consumes them, that is, without ?0 and ?name.
+func countPositionalArgs(query string) int {
+	var n int
+	p := parser.NewString(query)
+	for p.Valid() {
+		b, ok := p.ReadSep('?')
+		if !ok {
+			continue
+		}
+		if len(b) > 0 && b[len(b)-1] == '\\' {
+			continue
+		}
+		if name, _ := p.ReadIdentifier(); name == "" {
+			n++
+		}
+	}
+	return n
+}
+
+func onClauseString(on, action schema.QueryWithArgs) string {
+	if action.IsZero() {
+		return on.Query
+	}
+	return on.Query + " " + action.Query
+}
+
+// OnConflictWhere adds a predicate to the conflict target so PostgreSQL and SQLite
+// can infer a partial unique index:
+//
+//    On("CONFLICT (email) DO NOTHING").OnConflictWhere("deleted_at IS NULL")
+//
+// produces ON CONFLICT (email) WHERE deleted_at IS NULL DO NOTHING.
+func (q *InsertQuery) OnConflictWhere(query string, args ...interface{}) *InsertQuery {
+	if !q.hasFeature(feature.InsertOnConflict) {
+		q.setErr(fmt.Errorf("bun: %s does not support OnConflictWhere", q.db.Dialect().Name()))
+		return q
+	}
+	q.conflictWhere = schema.SafeQuery(query, args)
+	return q
+}
+
+// UpsertAllExcept updates all data fields except the excluded columns and
+// the conflict columns when the row already exists, for example:
+//
+//    UpsertAllExcept([]string{"email"}, "created_at")
+//
+// produces ON CONFLICT ("email") DO UPDATE SET name = EXCLUDED.name, ...
+// MySQL ignores the conflict columns and uses ON DUPLICATE KEY UPDATE instead.
+func (q *InsertQuery) UpsertAllExcept(conflictCols []string, exclude ...string) *InsertQuery {
+	if q.table == nil {
+		q.setErr(fmt.Errorf("bun: got %T, but UpsertAllExcept requires a struct or slice-based model", q.model))
+		return q
+	}
+
+	q.upsertExclude = make(map[string]struct{}, len(conflictCols)+len(exclude))
+	for _, col := range conflictCols {
+		q.upsertExclude[col] = struct{}{}
+	}
+	for _, col := range exclude {
+		q.upsertExclude[col] = struct{}{}
+	}
+
+	if q.hasFeature(feature.InsertOnDuplicateKey) {
+		q.setOn(schema.SafeQuery("DUPLICATE KEY UPDATE", nil), schema.QueryWithArgs{})
+		return q
+	}
+
+	if len(conflictCols) == 0 {
+		q.setErr(errors.New("bun: UpsertAllExcept requires conflict columns"))
+		return q
+	}
+
+	idents := make([]interface{}, len(conflictCols))
+	for i, col := range conflictCols {
+		idents[i] = schema.Ident(col)
+	}
+	q.setOn(
+		schema.SafeQuery("CONFLICT (?)", []interface{}{schema.In(idents)}),
+		schema.SafeQuery("DO UPDATE", nil),
+	)
+	return q
+}
+
+// OnConflictDoUpdate adds ON CONFLICT (columns) DO UPDATE. Without columns,
+// the primary keys are used as the conflict target. Use Set to choose the
+// updated columns and Where to update only when a condition holds, for example,
+// when the incoming row is newer:
+//
+//    db.NewInsert().Model(&item).
+//        OnConflictDoUpdate("id").
+//        Set("updated_at = EXCLUDED.updated_at").
+//        Where("EXCLUDED.updated_at > item.updated_at")
+//
+// The incoming row is available as EXCLUDED and the existing row as the
+// model alias. Without Set, all data fields are updated from EXCLUDED.
+// MySQL uses ON DUPLICATE KEY UPDATE instead, which does not support WHERE.
+func (q *InsertQuery) OnConflictDoUpdate(columns ...string) *InsertQuery {
+	if q.hasFeature(feature.InsertOnDuplicateKey) {
+		q.setOn(schema.SafeQuery("DUPLICATE KEY UPDATE", nil), schema.QueryWithArgs{})
+		return q
+	}
+	if !q.hasFeature(feature.InsertOnConflict) {
+		q.setErr(fmt.Errorf("bun: %s does not support OnConflictDoUpdate", q.db.Dialect().Name()))
+		return q
+	}
+
+	idents := make([]interface{}, 0, len(columns))
+	for _, col := range columns {
+		idents = append(idents, schema.Ident(col))
+	}
+	if len(idents) == 0 {
+		if q.table == nil || len(q.table.PKs) == 0 {
+			q.setErr(errors.New("bun: OnConflictDoUpdate requires conflict columns"))
+			return q
+		}
+		for _, pk := range q.table.PKs {
+			idents = append(idents, schema.Ident(pk.Name))
+		}
+	}
+	q.setOn(
+		schema.SafeQuery("CONFLICT (?)", []interface{}{schema.In(idents)}),
+		schema.SafeQuery("DO UPDATE", nil),
+	)
 	return q
 }
 
 func (q *InsertQuery) Set(query string, args ...interface{}) *InsertQuery {
-	q.addSet(schema.SafeQuery(query, args))
+	if set := schema.SafeQuery(query, args); q.checkSafeQuery(set) {
+		q.addSet(set)
+	}
 	return q
 }
 
@@ -445,6 +714,26 @@ func (q *InsertQuery) appendOn(fmter schema.Formatter, b []byte) (_ []byte, err
 		return nil, err
 	}
 
+	if !q.conflictWhere.IsZero() {
+		if q.onAction.IsZero() {
+			return nil, fmt.Errorf("bun: OnConflictWhere requires ON CONFLICT ... DO, got %q", q.on.Query)
+		}
+
+		b = append(b, " WHERE "...)
+		b, err = q.conflictWhere.AppendQuery(fmter, b)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !q.onAction.IsZero() {
+		b = append(b, ' ')
+		b, err = q.onAction.AppendQuery(fmter, b)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if len(q.set) > 0 {
 		if fmter.HasFeature(feature.InsertOnDuplicateKey) {
 			b = append(b, ' ')
@@ -457,30 +746,25 @@ func (q *InsertQuery) appendOn(fmter schema.Formatter, b []byte) (_ []byte, err
 			return nil, err
 		}
 	} else if q.onConflictDoUpdate() {
-		fields, err := q.getDataFields()
+		fields, err := q.getUpsertFields()
 		if err != nil {
 			return nil, err
 		}
 
-		if len(fields) == 0 {
-			fields = q.tableModel.Table().DataFields
-		}
-
 		b = q.appendSetExcluded(b, fields)
 	} else if q.onDuplicateKeyUpdate() {
-		fields, err := q.getDataFields()
+		fields, err := q.getUpsertFields()
 		if err != nil {
 			return nil, err
 		}
 
-		if len(fields) == 0 {
-			fields = q.tableModel.Table().DataFields
-		}
-
 		b = q.appendSetValues(b, fields)
 	}
 
 	if len(q.where) > 0 {
+		if q.onDuplicateKeyUpdate() {
+			return nil, errors.New("bun: ON DUPLICATE KEY UPDATE does not support WHERE")
+		}
 		b = append(b, " WHERE "...)
 
 		b, err = appendWhere(fmter, b, q.where)
@@ -492,8 +776,34 @@ func (q *InsertQuery) appendOn(fmter schema.Formatter, b []byte) (_ []byte, err
 	return b, nil
 }
 
+func (q *InsertQuery) getUpsertFields() ([]*schema.Field, error) {
+	fields, err := q.getDataFields()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fields) == 0 {
+		fields = q.tableModel.Table().DataFields
+	}
+
+	if q.upsertExclude == nil {
+		return fields, nil
+	}
+
+	filtered := make([]*schema.Field, 0, len(fields))
+	for _, f := range fields {
+		if _, ok := q.upsertExclude[f.Name]; !ok {
+			filtered = append(filtered, f)
+		}
+	}
+	if len(filtered) == 0 {
+		return nil, errors.New("bun: UpsertAllExcept excluded all columns")
+	}
+	return filtered, nil
+}
+
 func (q *InsertQuery) onConflictDoUpdate() bool {
-	return strings.HasSuffix(strings.ToUpper(q.on.Query), " DO UPDATE")
+	return strings.EqualFold(q.onAction.Query, "DO UPDATE")
 }
 
 func (q *InsertQuery) onDuplicateKeyUpdate() bool {
@@ -650,3 +960,9 @@ func (q *InsertQuery) String() string {
 
 	return string(buf)
 }
+
+// PrettyString is like String, but starts every top-level clause on a new line.
+// It is meant for logging and tests and does not change the executed query.
+func (q *InsertQuery) PrettyString() string {
+	return prettyQuery(q.String())
+}
@@ -2,6 +2,8 @@ package bun
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 
 	"github.com/uptrace/bun/schema"
 )
@@ -26,19 +28,77 @@ func (db *DB) Raw(query string, args ...interface{}) *RawQuery {
 
 func (q *RawQuery) Scan(ctx context.Context, dest ...interface{}) error {
 	if q.err != nil {
+		closeChanDest(dest)
 		return q.err
 	}
 
 	model, err := q.getModel(dest)
 	if err != nil {
+		closeChanDest(dest)
 		return err
 	}
+	defer closeChanModel(model)
 
 	query := q.db.format(q.query, q.args)
 	_, err = q.scan(ctx, q, query, model, true)
 	return err
 }
 
+// ScanMulti executes a query that returns multiple result sets, for example,
+// a batch of statements or a stored procedure, and scans each result set
+// into the corresponding destination:
+//
+//    var users []User
+//    var count int
+//    err := db.Raw("SELECT * FROM users; SELECT count(*) FROM users").
+//        ScanMulti(ctx, &users, &count)
+//
+// The driver must support sql.Rows.NextResultSet.
+func (q *RawQuery) ScanMulti(ctx context.Context, dest ...interface{}) error {
+	if q.err != nil {
+		return q.err
+	}
+
+	models := make([]Model, len(dest))
+	for i, d := range dest {
+		model, err := newModel(q.db, []interface{}{d})
+		if err != nil {
+			return err
+		}
+		models[i] = model
+	}
+
+	query := q.db.format(q.query, q.args)
+	ctx, event := q.db.beforeQuery(ctx, q, query, nil, query, nil)
+
+	rows, err := q.queryConn().QueryContext(ctx, query)
+	if err != nil {
+		q.db.afterQuery(ctx, event, nil, err)
+		return err
+	}
+	defer rows.Close()
+
+	err = scanResultSets(ctx, rows, models)
+	q.db.afterQuery(ctx, event, nil, err)
+	return err
+}
+
+func scanResultSets(ctx context.Context, rows *sql.Rows, models []Model) error {
+	for i, model := range models {
+		if i > 0 && !rows.NextResultSet() {
+			if err := rows.Err(); err != nil {
+				return err
+			}
+			return fmt.Errorf("bun: got %d result sets, but ScanMulti has %d destinations",
+				i, len(models))
+		}
+		if _, err := model.ScanRows(ctx, rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 func (q *RawQuery) AppendQuery(fmter schema.Formatter, b []byte) ([]byte, error) {
 	return fmter.AppendQuery(b, q.query, q.args...), nil
 }
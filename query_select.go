@@ -9,19 +9,31 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
-	"github.com/uptrace/bun/dialect"
 
+	"github.com/uptrace/bun/dialect"
 	"github.com/uptrace/bun/dialect/feature"
 	"github.com/uptrace/bun/internal"
 	"github.com/uptrace/bun/schema"
 )
 
+// limitAll is the limit set by LimitAll. Like the default -1, it is negative,
+// so the query is not limited.
+const limitAll = -2
+
 type union struct {
 	expr  string
 	query *SelectQuery
 }
 
+// orderLimit holds the ORDER BY, LIMIT, and OFFSET of a query.
+type orderLimit struct {
+	order  []schema.QueryWithArgs
+	limit  int32
+	offset int32
+}
+
 type SelectQuery struct {
 	whereBaseQuery
 	idxHintsQuery
@@ -34,8 +46,20 @@ type SelectQuery struct {
 	limit      int32
 	offset     int32
 	selFor     schema.QueryWithArgs
+	sample     schema.QueryWithArgs
+
+	withTotalCount bool
+	totalCount     int
+	fetchSize      int
+	slowLog        time.Duration
+
+	into     schema.QueryWithArgs
+	intoTemp bool
 
 	union []union
+	// unionFirst is the ORDER BY and LIMIT of the first operand of a compound
+	// select, because the ones set after Union apply to the combined result.
+	unionFirst orderLimit
 }
 
 var _ Query = (*SelectQuery)(nil)
@@ -44,8 +68,9 @@ func NewSelectQuery(db *DB) *SelectQuery {
 	return &SelectQuery{
 		whereBaseQuery: whereBaseQuery{
 			baseQuery: baseQuery{
-				db:   db,
-				conn: db.DB,
+				db:    db,
+				conn:  db.DB,
+				flags: db.queryFlags(),
 			},
 		},
 		offset: -1,
@@ -73,6 +98,20 @@ func (q *SelectQuery) With(name string, query schema.QueryAppender) *SelectQuery
 	return q
 }
 
+// UseReplica runs the query on a read replica registered with DB.AddReplica.
+// It has no effect when the query runs on a connection or in a transaction.
+func (q *SelectQuery) UseReplica() *SelectQuery {
+	q.flags = q.flags.Set(useReplicaFlag)
+	return q
+}
+
+// Only adds the ONLY keyword before the table name so PostgreSQL does not
+// include rows from tables that inherit from it.
+func (q *SelectQuery) Only() *SelectQuery {
+	q.flags = q.flags.Set(onlyFlag)
+	return q
+}
+
 func (q *SelectQuery) Distinct() *SelectQuery {
 	q.distinctOn = make([]schema.QueryWithArgs, 0)
 	return q
@@ -102,6 +141,13 @@ func (q *SelectQuery) ModelTableExpr(query string, args ...interface{}) *SelectQ
 	return q
 }
 
+// Schema qualifies unqualified table names with the schema.
+// It overrides the schema set with DB.WithSchema.
+func (q *SelectQuery) Schema(name string) *SelectQuery {
+	q.schemaName = name
+	return q
+}
+
 //------------------------------------------------------------------------------
 
 func (q *SelectQuery) Column(columns ...string) *SelectQuery {
@@ -116,6 +162,14 @@ func (q *SelectQuery) ColumnExpr(query string, args ...interface{}) *SelectQuery
 	return q
 }
 
+// ExcludeColumn removes the columns from the selection. When no columns were
+// selected yet, it starts with all model columns. When called after Column,
+// it only removes columns from those already selected and excluding a model
+// column that is not selected is an error:
+//
+//    db.NewSelect().Model(&user).Column("id", "name").ExcludeColumn("name")
+//
+// Use ExcludeColumn("*") to select no model columns at all.
 func (q *SelectQuery) ExcludeColumn(columns ...string) *SelectQuery {
 	q.excludeColumn(columns)
 	return q
@@ -128,6 +182,16 @@ func (q *SelectQuery) WherePK(cols ...string) *SelectQuery {
 	return q
 }
 
+// WherePKSorted is like WherePK, but sorts primary key values of a slice-based
+// model so the same set of rows always produces the same query.
+func (q *SelectQuery) WherePKSorted(cols ...string) *SelectQuery {
+	q.flags = q.flags.Set(wherePKSortedFlag)
+	q.addWhereCols(cols)
+	return q
+}
+
+// Where adds a WHERE condition. WHERE is evaluated before aggregation,
+// so conditions on aggregates such as count(*) must be added with Having.
 func (q *SelectQuery) Where(query string, args ...interface{}) *SelectQuery {
 	q.addWhere(schema.SafeQueryWithSep(query, args, " AND "))
 	return q
@@ -138,6 +202,22 @@ func (q *SelectQuery) WhereOr(query string, args ...interface{}) *SelectQuery {
 	return q
 }
 
+// WhereIf adds a WHERE condition joined with AND only when cond is true.
+func (q *SelectQuery) WhereIf(cond bool, query string, args ...interface{}) *SelectQuery {
+	if cond {
+		q.addWhere(schema.SafeQueryWithSep(query, args, " AND "))
+	}
+	return q
+}
+
+// WhereOrIf adds a WHERE condition joined with OR only when cond is true.
+func (q *SelectQuery) WhereOrIf(cond bool, query string, args ...interface{}) *SelectQuery {
+	if cond {
+		q.addWhere(schema.SafeQueryWithSep(query, args, " OR "))
+	}
+	return q
+}
+
 func (q *SelectQuery) WhereGroup(sep string, fn func(*SelectQuery) *SelectQuery) *SelectQuery {
 	saved := q.where
 	q.where = nil
@@ -152,6 +232,134 @@ func (q *SelectQuery) WhereGroup(sep string, fn func(*SelectQuery) *SelectQuery)
 	return q
 }
 
+// WhereOrQuery appends WHERE conditions of the other query as a parenthesized
+// group that is joined with OR, for example, WHERE (a) OR ((b) AND (c)).
+func (q *SelectQuery) WhereOrQuery(other *SelectQuery) *SelectQuery {
+	q.addWhereQuery(" OR ", &other.whereBaseQuery)
+	return q
+}
+
+// WhereSubAny adds a condition comparing the column with any row
+// of the subquery, for example, WHERE ("price" = ANY (SELECT ...)).
+// SQLite does not support ANY with a subquery.
+func (q *SelectQuery) WhereSubAny(column, op string, query schema.QueryAppender) *SelectQuery {
+	q.addWhereSub(column, op, "ANY", query)
+	return q
+}
+
+// WhereSubAll adds a condition comparing the column with all rows
+// of the subquery, for example, WHERE ("price" > ALL (SELECT ...)).
+// SQLite does not support ALL with a subquery.
+func (q *SelectQuery) WhereSubAll(column, op string, query schema.QueryAppender) *SelectQuery {
+	q.addWhereSub(column, op, "ALL", query)
+	return q
+}
+
+// WhereInFunc adds a `column IN (...)` condition with values pulled from next
+// until it returns false. The number of values is limited to 10000.
+func (q *SelectQuery) WhereInFunc(column string, next func() (interface{}, bool)) *SelectQuery {
+	q.addWhereInFunc(column, next)
+	return q
+}
+
+// WhereInValuesJoin adds a `column IN (...)` condition that PostgreSQL renders
+// as a VALUES constructor, for example, `id IN (VALUES (1), (2), (3))`.
+// PostgreSQL plans it as a hash semi-join, which is usually faster than an IN
+// list with thousands of values; see BenchmarkWhereInValuesJoin. Dialects
+// without feature.InValues use a regular IN list.
+func (q *SelectQuery) WhereInValuesJoin(column string, values interface{}) *SelectQuery {
+	q.addWhereInValuesJoin(column, values)
+	return q
+}
+
+// WhereRaw adds the SQL condition verbatim without processing placeholders,
+// which allows operators such as the PostgreSQL jsonb `?` operator:
+//
+//    q.WhereRaw(`attrs ? 'color'`)
+//
+// The condition is not escaped. Never pass user input to WhereRaw.
+func (q *SelectQuery) WhereRaw(query string) *SelectQuery {
+	q.addWhereRaw(query)
+	return q
+}
+
+// WhereInChunked adds a `column IN (...)` condition that is split into groups
+// of at most 1000 values joined with OR, for example,
+// `("id" IN (1, ...) OR "id" IN (1001, ...))`. It is meant for databases that
+// limit the number of items in one IN list, for example, Oracle allows 1000.
+// The values are inlined like with In, so it does not reduce the number of
+// query parameters.
+func (q *SelectQuery) WhereInChunked(column string, values interface{}) *SelectQuery {
+	q.addWhereInChunked(column, values)
+	return q
+}
+
+// WhereSTDWithin adds a PostGIS condition that matches rows within the distance
+// in meters from the point, for example:
+//
+//    q.WhereSTDWithin("location", -73.9857, 40.7484, 500)
+//
+// The coordinates are longitude and latitude in WGS 84 (SRID 4326), and the
+// column is cast to geography. Only PostgreSQL with PostGIS supports it.
+func (q *SelectQuery) WhereSTDWithin(column string, lon, lat, meters float64) *SelectQuery {
+	q.addWhereSTDWithin(column, lon, lat, meters)
+	return q
+}
+
+// WhereTrigramSimilar adds a pg_trgm fuzzy match condition, for example,
+// `"name" % 'jon' AND similarity("name", 'jon') >= 0.5`. The % operator
+// can use a trigram index and matches rows above pg_trgm.similarity_threshold
+// (0.3 by default), so thresholds below it have no effect. Use 0 to rely on
+// the % operator only. Only PostgreSQL with pg_trgm supports it.
+func (q *SelectQuery) WhereTrigramSimilar(column, query string, threshold float64) *SelectQuery {
+	q.addWhereTrigramSimilar(column, query, threshold)
+	return q
+}
+
+// WhereCollate adds a comparison that uses the collation, for example,
+// WHERE ("name" = 'foo' COLLATE "C") on PostgreSQL.
+func (q *SelectQuery) WhereCollate(column, op string, value interface{}, collation string) *SelectQuery {
+	q.addWhereCollate(column, op, value, collation)
+	return q
+}
+
+// WhereTupleGreater adds a row comparison for keyset pagination over
+// composite sort keys, for example, WHERE (("a", "b") > (1, 2)).
+// NULL values are rejected because a comparison with NULL matches no rows.
+// Paginate over nullable columns with COALESCE in both OrderExpr and Where.
+func (q *SelectQuery) WhereTupleGreater(columns []string, values []interface{}) *SelectQuery {
+	q.addWhereTuple(">", columns, values)
+	return q
+}
+
+func (q *SelectQuery) WhereTupleGreaterOrEqual(columns []string, values []interface{}) *SelectQuery {
+	q.addWhereTuple(">=", columns, values)
+	return q
+}
+
+func (q *SelectQuery) WhereTupleLess(columns []string, values []interface{}) *SelectQuery {
+	q.addWhereTuple("<", columns, values)
+	return q
+}
+
+func (q *SelectQuery) WhereTupleLessOrEqual(columns []string, values []interface{}) *SelectQuery {
+	q.addWhereTuple("<=", columns, values)
+	return q
+}
+
+// Conditions returns a copy of the WHERE conditions added so far.
+// Groups are represented by items with "(" and ")" separators.
+func (q *SelectQuery) Conditions() []schema.QueryWithSep {
+	return q.conditions()
+}
+
+// SetConditions replaces the WHERE conditions, for example,
+// with conditions returned by Conditions and then reordered.
+func (q *SelectQuery) SetConditions(where []schema.QueryWithSep) *SelectQuery {
+	q.setConditions(where)
+	return q
+}
+
 func (q *SelectQuery) WhereDeleted() *SelectQuery {
 	q.whereDeleted()
 	return q
@@ -262,6 +470,12 @@ func (q *SelectQuery) GroupExpr(group string, args ...interface{}) *SelectQuery
 	return q
 }
 
+// Having adds a HAVING condition. Soft delete conditions are always added
+// to the WHERE clause so deleted rows are filtered out before aggregation.
+//
+// MySQL and SQLite allow referencing select aliases, for example,
+// Having("total > ?", 10), but PostgreSQL and MSSQL require repeating
+// the aggregate expression, for example, Having("count(*) > ?", 10).
 func (q *SelectQuery) Having(having string, args ...interface{}) *SelectQuery {
 	q.having = append(q.having, schema.SafeQuery(having, args))
 	return q
@@ -301,16 +515,115 @@ func (q *SelectQuery) OrderExpr(query string, args ...interface{}) *SelectQuery
 	return q
 }
 
+// OrderTrigramSimilarity orders rows by pg_trgm similarity to the query,
+// most similar first, for example, `ORDER BY similarity("name", 'jon') DESC`.
+// It is usually combined with WhereTrigramSimilar.
+func (q *SelectQuery) OrderTrigramSimilarity(column, query string) *SelectQuery {
+	if !q.hasFeature(feature.Trigram) {
+		q.setErr(fmt.Errorf("bun: %s does not support pg_trgm", q.db.Dialect().Name()))
+		return q
+	}
+	q.order = append(q.order, schema.SafeQuery("?", []interface{}{&trigramExpr{
+		column: column,
+		query:  query,
+		order:  true,
+	}}))
+	return q
+}
+
+// OrderOrdinal orders by the 1-based positions of the selected columns,
+// for example, OrderOrdinal(1, 2) produces `ORDER BY 1, 2`.
+func (q *SelectQuery) OrderOrdinal(positions ...int) *SelectQuery {
+	for _, pos := range positions {
+		if pos < 1 {
+			q.setErr(fmt.Errorf("bun: OrderOrdinal got position %d, but positions start at 1", pos))
+			return q
+		}
+		q.order = append(q.order, schema.SafeQuery(strconv.Itoa(pos), nil))
+	}
+	return q
+}
+
 func (q *SelectQuery) Limit(n int) *SelectQuery {
 	q.limit = int32(n)
 	return q
 }
 
+// LimitAll removes the limit. PostgreSQL renders it as `LIMIT ALL`
+// and other dialects omit the LIMIT clause.
+func (q *SelectQuery) LimitAll() *SelectQuery {
+	q.limit = limitAll
+	return q
+}
+
+// FetchSize asks the connection to fetch n rows per round trip when the query
+// is scanned. The size is passed in the context, see FetchSizeFromContext
+// for the driver requirements. Drivers that don't support it ignore it.
+func (q *SelectQuery) FetchSize(n int) *SelectQuery {
+	q.fetchSize = n
+	return q
+}
+
+// IntoTable creates a new table from the query result using SELECT ... INTO,
+// for example, `SELECT * INTO "archive" FROM "books"`. Unlike CREATE TABLE AS,
+// it is a SELECT statement and only PostgreSQL and MSSQL support it.
+// MSSQL creates a temporary table when the name starts with '#'.
+func (q *SelectQuery) IntoTable(name string) *SelectQuery {
+	q.into = schema.UnsafeIdent(name)
+	q.intoTemp = false
+	return q
+}
+
+// IntoTempTable is like IntoTable, but creates a temporary table
+// using SELECT ... INTO TEMP. Only PostgreSQL supports it.
+func (q *SelectQuery) IntoTempTable(name string) *SelectQuery {
+	q.into = schema.UnsafeIdent(name)
+	q.intoTemp = true
+	return q
+}
+
+// SlowLog logs the query with the bun logger (see SetLogger) when it runs
+// longer than the threshold.
+func (q *SelectQuery) SlowLog(threshold time.Duration) *SelectQuery {
+	q.slowLog = threshold
+	return q
+}
+
+// withOptions adds the fetch size and the slow query threshold to the context
+// of the query execution.
+func (q *SelectQuery) withOptions(ctx context.Context) context.Context {
+	if q.fetchSize > 0 {
+		ctx = WithFetchSize(ctx, q.fetchSize)
+	}
+	if q.slowLog > 0 {
+		ctx = withSlowLog(ctx, q.slowLog)
+	}
+	return ctx
+}
+
 func (q *SelectQuery) Offset(n int) *SelectQuery {
 	q.offset = int32(n)
 	return q
 }
 
+// TableSample adds a PostgreSQL TABLESAMPLE clause that reads approximately
+// pct percent of the table rows. The method must be BERNOULLI or SYSTEM
+// and pct must be between 0 and 100.
+func (q *SelectQuery) TableSample(method string, pct float64) *SelectQuery {
+	if !(pct >= 0 && pct <= 100) {
+		q.setErr(fmt.Errorf("bun: TABLESAMPLE percentage must be between 0 and 100, got %v", pct))
+		return q
+	}
+
+	switch method = strings.ToUpper(method); method {
+	case "BERNOULLI", "SYSTEM":
+		q.sample = schema.SafeQuery(method+" (?)", []interface{}{pct})
+	default:
+		q.setErr(fmt.Errorf("bun: unsupported TABLESAMPLE method %q", method))
+	}
+	return q
+}
+
 func (q *SelectQuery) For(s string, args ...interface{}) *SelectQuery {
 	q.selFor = schema.SafeQuery(s, args)
 	return q
@@ -318,6 +631,13 @@ func (q *SelectQuery) For(s string, args ...interface{}) *SelectQuery {
 
 //------------------------------------------------------------------------------
 
+// Union combines the query with other query as (q) UNION (other).
+// Each operand is wrapped in parentheses together with its own ORDER BY
+// and LIMIT. Order, Limit, and Offset called after Union sort and limit
+// the combined result and are appended after the last operand:
+//
+//    q1.Union(q2).Order("id").Limit(10)
+//    // (SELECT ...) UNION (SELECT ...) ORDER BY "id" LIMIT 10
 func (q *SelectQuery) Union(other *SelectQuery) *SelectQuery {
 	return q.addUnion(" UNION ", other)
 }
@@ -343,6 +663,12 @@ func (q *SelectQuery) ExceptAll(other *SelectQuery) *SelectQuery {
 }
 
 func (q *SelectQuery) addUnion(expr string, other *SelectQuery) *SelectQuery {
+	if len(q.union) == 0 {
+		q.unionFirst = q.orderLimit()
+		q.order = nil
+		q.limit = -1
+		q.offset = -1
+	}
 	q.union = append(q.union, union{
 		expr:  expr,
 		query: other,
@@ -460,9 +786,9 @@ func (q *SelectQuery) selectJoins(ctx context.Context, joins []relationJoin) err
 		case schema.HasOneRelation, schema.BelongsToRelation:
 			err = q.selectJoins(ctx, j.JoinModel.getJoins())
 		case schema.HasManyRelation:
-			err = j.selectMany(ctx, q.db.NewSelect().Conn(q.conn))
+			err = j.selectMany(ctx, q.db.NewSelect().Conn(q.conn).Schema(q.schemaName))
 		case schema.ManyToManyRelation:
-			err = j.selectM2M(ctx, q.db.NewSelect().Conn(q.conn))
+			err = j.selectM2M(ctx, q.db.NewSelect().Conn(q.conn).Schema(q.schemaName))
 		default:
 			panic("not reached")
 		}
@@ -484,6 +810,13 @@ func (q *SelectQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte, e
 	return q.appendQuery(fmter, b, false)
 }
 
+// AppendQueryArgs is like AppendQuery, but appends placeholders instead of
+// query arguments and returns the arguments separately, for example,
+// to reuse a prepared statement.
+func (q *SelectQuery) AppendQueryArgs(fmter schema.Formatter, b []byte) ([]byte, []interface{}, error) {
+	return appendQueryArgs(fmter, b, q)
+}
+
 func (q *SelectQuery) appendQuery(
 	fmter schema.Formatter, b []byte, count bool,
 ) (_ []byte, err error) {
@@ -491,7 +824,7 @@ func (q *SelectQuery) appendQuery(
 		return nil, q.err
 	}
 
-	fmter = formatterWithModel(fmter, q)
+	fmter = formatterWithModel(fmter, q.db, q)
 
 	cteCount := count && (len(q.group) > 0 || q.distinctOn != nil)
 	if cteCount {
@@ -532,6 +865,11 @@ func (q *SelectQuery) appendQuery(
 		if err != nil {
 			return nil, err
 		}
+
+		b, err = q.appendInto(fmter, b)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if q.hasTables() {
@@ -595,40 +933,14 @@ func (q *SelectQuery) appendQuery(
 	}
 
 	if !count {
-		b, err = q.appendOrder(fmter, b)
-		if err != nil {
-			return nil, err
+		ol := q.orderLimit()
+		if len(q.union) > 0 {
+			ol = q.unionFirst
 		}
 
-		if fmter.Dialect().Features().Has(feature.OffsetFetch) {
-			if q.limit >= 0 && q.offset >= 0 {
-				b = append(b, " OFFSET "...)
-				b = strconv.AppendInt(b, int64(q.offset), 10)
-				b = append(b, " ROWS"...)
-
-				b = append(b, " FETCH NEXT "...)
-				b = strconv.AppendInt(b, int64(q.limit), 10)
-				b = append(b, " ROWS ONLY"...)
-			} else if q.limit >= 0 {
-				b = append(b, " OFFSET 0 ROWS"...)
-
-				b = append(b, " FETCH NEXT "...)
-				b = strconv.AppendInt(b, int64(q.limit), 10)
-				b = append(b, " ROWS ONLY"...)
-			} else if q.offset >= 0 {
-				b = append(b, " OFFSET "...)
-				b = strconv.AppendInt(b, int64(q.offset), 10)
-				b = append(b, " ROWS"...)
-			}
-		} else {
-			if q.limit >= 0 {
-				b = append(b, " LIMIT "...)
-				b = strconv.AppendInt(b, int64(q.limit), 10)
-			}
-			if q.offset >= 0 {
-				b = append(b, " OFFSET "...)
-				b = strconv.AppendInt(b, int64(q.offset), 10)
-			}
+		b, err = ol.appendQuery(fmter, b)
+		if err != nil {
+			return nil, err
 		}
 
 		if !q.selFor.IsZero() {
@@ -652,6 +964,13 @@ func (q *SelectQuery) appendQuery(
 			}
 			b = append(b, ')')
 		}
+
+		if !count {
+			b, err = q.orderLimit().appendQuery(fmter, b)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	if cteCount {
@@ -661,6 +980,75 @@ func (q *SelectQuery) appendQuery(
 	return b, nil
 }
 
+// appendCachedQuery is like AppendQuery, but uses the query cache
+// enabled with DB.WithQueryCache.
+func (q *SelectQuery) appendCachedQuery(fmter schema.Formatter, b []byte) ([]byte, error) {
+	if q.db.queryCache == nil {
+		return q.AppendQuery(fmter, b)
+	}
+
+	shape := getQueryShape()
+	defer putQueryShape(shape)
+
+	if !q.appendShape(shape) {
+		return q.AppendQuery(fmter, b)
+	}
+	return q.db.queryCache.appendQuery(fmter, b, q, q.table, shape)
+}
+
+// appendShape adds everything appendQuery formats, except argument values,
+// to the shape. It returns false if the query can't be cached, for example,
+// because it has relations, CTEs, or unions.
+func (q *SelectQuery) appendShape(s *queryShape) bool {
+	if q.err != nil || q.with != nil || q.union != nil || q.whereFields != nil ||
+		q.use != nil || q.ignore != nil || q.force != nil {
+		return false
+	}
+	if q.tableModel != nil && len(q.tableModel.getJoins()) > 0 {
+		return false
+	}
+
+	s.addInt(int64(q.flags))
+	s.addString(q.schemaName)
+	s.addInt(int64(q.limit))
+	s.addInt(int64(q.offset))
+	s.addBool(q.distinctOn != nil)
+	s.addBool(q.withTotalCount)
+	s.addBool(q.intoTemp)
+
+	if !s.addQuery(q.modelTableName) ||
+		!s.addQueries(q.tables) ||
+		!s.addQueries(q.columns) ||
+		!s.addQueries(q.distinctOn) ||
+		!s.addQueriesWithSep(q.where) ||
+		!s.addQueries(q.group) ||
+		!s.addQueries(q.having) ||
+		!s.addQueries(q.order) ||
+		!s.addQuery(q.selFor) ||
+		!s.addQuery(q.sample) ||
+		!s.addQuery(q.into) {
+		return false
+	}
+
+	s.addInt(int64(len(q.joins)))
+	for _, j := range q.joins {
+		if !s.addQuery(j.join) || !s.addQueriesWithSep(j.on) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// appendNoLimit appends a LIMIT that does not restrict the number of rows
+// for dialects that don't support OFFSET without LIMIT.
+func appendNoLimit(fmter schema.Formatter, b []byte) []byte {
+	if d, ok := fmter.Dialect().(schema.NoLimitAppender); ok {
+		return d.AppendNoLimit(b)
+	}
+	return b
+}
+
 func (q *SelectQuery) appendColumns(fmter schema.Formatter, b []byte) (_ []byte, err error) {
 	start := len(b)
 
@@ -715,6 +1103,11 @@ func (q *SelectQuery) appendColumns(fmter schema.Formatter, b []byte) (_ []byte,
 
 	b = bytes.TrimSuffix(b, []byte(", "))
 
+	if q.withTotalCount {
+		b = append(b, ", count(*) OVER () AS "...)
+		b = fmter.AppendIdent(b, totalCountColumn)
+	}
+
 	return b, nil
 }
 
@@ -762,16 +1155,62 @@ func (q *SelectQuery) appendInlineRelColumns(
 	return b, nil
 }
 
+func (q *SelectQuery) appendInto(fmter schema.Formatter, b []byte) (_ []byte, err error) {
+	if q.into.IsZero() {
+		return b, nil
+	}
+
+	if !fmter.HasFeature(feature.SelectInto) {
+		return nil, fmt.Errorf("bun: %s does not support SELECT ... INTO", fmter.Dialect().Name())
+	}
+	if q.intoTemp && !fmter.HasFeature(feature.SelectIntoTemp) {
+		return nil, fmt.Errorf("bun: %s does not support INTO TEMP", fmter.Dialect().Name())
+	}
+
+	b = append(b, " INTO "...)
+	if q.intoTemp {
+		b = append(b, "TEMP "...)
+	}
+	return q.appendTable(fmter, b, q.into)
+}
+
 func (q *SelectQuery) appendTables(fmter schema.Formatter, b []byte) (_ []byte, err error) {
 	b = append(b, " FROM "...)
-	return q.appendTablesWithAlias(fmter, b)
+	b, err = q.appendTablesWithAlias(fmter, b)
+	if err != nil {
+		return nil, err
+	}
+
+	if !q.sample.IsZero() {
+		if !fmter.HasFeature(feature.TableSample) {
+			return nil, fmt.Errorf("bun: %s does not support TABLESAMPLE", fmter.Dialect().Name())
+		}
+		if q.hasMultiTables() {
+			return nil, errors.New("bun: TABLESAMPLE requires a single table")
+		}
+		b = append(b, " TABLESAMPLE "...)
+		b, err = q.sample.AppendQuery(fmter, b)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
 }
 
-func (q *SelectQuery) appendOrder(fmter schema.Formatter, b []byte) (_ []byte, err error) {
-	if len(q.order) > 0 {
+func (q *SelectQuery) orderLimit() orderLimit {
+	return orderLimit{
+		order:  q.order,
+		limit:  q.limit,
+		offset: q.offset,
+	}
+}
+
+func (ol orderLimit) appendQuery(fmter schema.Formatter, b []byte) (_ []byte, err error) {
+	if len(ol.order) > 0 {
 		b = append(b, " ORDER BY "...)
 
-		for i, f := range q.order {
+		for i, f := range ol.order {
 			if i > 0 {
 				b = append(b, ", "...)
 			}
@@ -780,41 +1219,276 @@ func (q *SelectQuery) appendOrder(fmter schema.Formatter, b []byte) (_ []byte, e
 				return nil, err
 			}
 		}
+	}
 
-		return b, nil
+	if fmter.Dialect().Features().Has(feature.OffsetFetch) {
+		if ol.limit >= 0 && ol.offset >= 0 {
+			b = append(b, " OFFSET "...)
+			b = strconv.AppendInt(b, int64(ol.offset), 10)
+			b = append(b, " ROWS"...)
+
+			b = append(b, " FETCH NEXT "...)
+			b = strconv.AppendInt(b, int64(ol.limit), 10)
+			b = append(b, " ROWS ONLY"...)
+		} else if ol.limit >= 0 {
+			b = append(b, " OFFSET 0 ROWS"...)
+
+			b = append(b, " FETCH NEXT "...)
+			b = strconv.AppendInt(b, int64(ol.limit), 10)
+			b = append(b, " ROWS ONLY"...)
+		} else if ol.offset >= 0 {
+			b = append(b, " OFFSET "...)
+			b = strconv.AppendInt(b, int64(ol.offset), 10)
+			b = append(b, " ROWS"...)
+		}
+	} else {
+		if ol.limit >= 0 {
+			b = append(b, " LIMIT "...)
+			b = strconv.AppendInt(b, int64(ol.limit), 10)
+		} else if ol.limit == limitAll && fmter.HasFeature(feature.LimitAll) {
+			b = append(b, " LIMIT ALL"...)
+		} else if ol.offset >= 0 {
+			b = appendNoLimit(fmter, b)
+		}
+		if ol.offset >= 0 {
+			b = append(b, " OFFSET "...)
+			b = strconv.AppendInt(b, int64(ol.offset), 10)
+		}
 	}
+
 	return b, nil
 }
 
 //------------------------------------------------------------------------------
 
+// WithTotalCount adds the `count(*) OVER ()` window column to the query so
+// the total number of rows ignoring LIMIT and OFFSET is available after Scan
+// via TotalCount without a separate count query. It requires a struct or
+// slice-based model.
+func (q *SelectQuery) WithTotalCount() *SelectQuery {
+	q.withTotalCount = true
+	return q
+}
+
+// TotalCount returns the total number of rows scanned by a query
+// with WithTotalCount. It returns 0 when the page is empty.
+func (q *SelectQuery) TotalCount() int {
+	return q.totalCount
+}
+
+// Rows executes the query and returns the rows for manual scanning.
+// The caller is responsible for closing the rows.
 func (q *SelectQuery) Rows(ctx context.Context) (*sql.Rows, error) {
 	if q.err != nil {
 		return nil, q.err
 	}
 
+	ctx = q.withOptions(ctx)
+
 	if err := q.beforeAppendModel(ctx, q); err != nil {
 		return nil, err
 	}
 
-	queryBytes, err := q.AppendQuery(q.db.fmter, q.db.makeQueryBytes())
+	queryBytes, err := q.appendCachedQuery(q.db.fmter, q.db.makeQueryBytes())
 	if err != nil {
 		return nil, err
 	}
 
 	query := internal.String(queryBytes)
-	return q.conn.QueryContext(ctx, query)
+
+	ctx, event := q.db.beforeQuery(ctx, q, query, nil, query, q.model)
+	rows, err := q.queryConn().QueryContext(ctx, query)
+	q.db.afterQuery(ctx, event, nil, err)
+	return rows, err
+}
+
+// ScanColumn executes the query and scans one column of every row into
+// the slice ignoring other columns. The column is either a 0-based index
+// or a column name, for example:
+//
+//    var names []string
+//    err := db.NewSelect().Column("id", "name").Table("users").ScanColumn(ctx, "name", &names)
+func (q *SelectQuery) ScanColumn(ctx context.Context, column interface{}, dest interface{}) error {
+	if q.err != nil {
+		return q.err
+	}
+
+	ctx = q.withOptions(ctx)
+
+	model, err := newColumnModel(column, dest)
+	if err != nil {
+		return err
+	}
+
+	if err := q.beforeAppendModel(ctx, q); err != nil {
+		return err
+	}
+
+	queryBytes, err := q.appendCachedQuery(q.db.fmter, q.db.makeQueryBytes())
+	if err != nil {
+		return err
+	}
+
+	query := internal.String(queryBytes)
+
+	_, err = q.scan(ctx, q, query, model, true)
+	return err
+}
+
+// ExplainOptions configures the EXPLAIN statement generated by SelectQuery.ExplainAnalyze.
+type ExplainOptions struct {
+	Analyze bool
+	// Buffers, Verbose, and Format are only supported by PostgreSQL.
+	Buffers bool
+	Verbose bool
+	// Format is the output format, for example, TEXT or JSON.
+	Format string
+}
+
+// Explain executes EXPLAIN for the query and returns the plan.
+// Each plan row is returned on a separate line with columns separated by tabs.
+func (q *SelectQuery) Explain(ctx context.Context) (string, error) {
+	return q.explain(ctx, ExplainOptions{})
+}
+
+// ExplainAnalyze is like Explain, but also executes the query to collect
+// actual run times, for example, EXPLAIN (ANALYZE, BUFFERS) on PostgreSQL.
+func (q *SelectQuery) ExplainAnalyze(ctx context.Context, opts ExplainOptions) (string, error) {
+	opts.Analyze = true
+	return q.explain(ctx, opts)
+}
+
+func (q *SelectQuery) explain(ctx context.Context, opts ExplainOptions) (string, error) {
+	if q.err != nil {
+		return "", q.err
+	}
+
+	if err := q.beforeAppendModel(ctx, q); err != nil {
+		return "", err
+	}
+
+	queryBytes, err := appendExplain(q.db.fmter, q.db.makeQueryBytes(), opts)
+	if err != nil {
+		return "", err
+	}
+
+	queryBytes, err = q.AppendQuery(q.db.fmter, queryBytes)
+	if err != nil {
+		return "", err
+	}
+
+	query := internal.String(queryBytes)
+
+	ctx, event := q.db.beforeQuery(ctx, q, query, nil, query, q.model)
+	rows, err := q.queryConn().QueryContext(ctx, query)
+	if err != nil {
+		q.db.afterQuery(ctx, event, nil, err)
+		return "", err
+	}
+	defer rows.Close()
+
+	plan, err := scanExplain(rows)
+	q.db.afterQuery(ctx, event, nil, err)
+	return plan, err
+}
+
+func appendExplain(fmter schema.Formatter, b []byte, opts ExplainOptions) ([]byte, error) {
+	name := fmter.Dialect().Name()
+	hasOptions := fmter.HasFeature(feature.ExplainOptions)
+
+	if !hasOptions && (opts.Buffers || opts.Verbose || opts.Format != "") {
+		return nil, fmt.Errorf("bun: %s does not support EXPLAIN options", name)
+	}
+
+	switch {
+	case hasOptions:
+		var options []string
+		if opts.Analyze {
+			options = append(options, "ANALYZE")
+		}
+		if opts.Buffers {
+			options = append(options, "BUFFERS")
+		}
+		if opts.Verbose {
+			options = append(options, "VERBOSE")
+		}
+		if opts.Format != "" {
+			switch format := strings.ToUpper(opts.Format); format {
+			case "TEXT", "JSON", "XML", "YAML":
+				options = append(options, "FORMAT "+format)
+			default:
+				return nil, fmt.Errorf("bun: unsupported EXPLAIN format %q", opts.Format)
+			}
+		}
+
+		b = append(b, "EXPLAIN "...)
+		if len(options) > 0 {
+			b = append(b, '(')
+			b = append(b, strings.Join(options, ", ")...)
+			b = append(b, ") "...)
+		}
+		return b, nil
+	case name == dialect.MySQL:
+		if opts.Analyze {
+			return append(b, "EXPLAIN ANALYZE "...), nil
+		}
+		return append(b, "EXPLAIN "...), nil
+	case name == dialect.SQLite:
+		if opts.Analyze {
+			return nil, errors.New("bun: sqlite does not support EXPLAIN ANALYZE")
+		}
+		return append(b, "EXPLAIN QUERY PLAN "...), nil
+	default:
+		return nil, fmt.Errorf("bun: %s does not support EXPLAIN", name)
+	}
+}
+
+func scanExplain(rows *sql.Rows) (string, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	var sb strings.Builder
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return "", err
+		}
+
+		if sb.Len() > 0 {
+			sb.WriteByte('\n')
+		}
+		for i, v := range values {
+			if i > 0 {
+				sb.WriteByte('\t')
+			}
+			sb.WriteString(v.String)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
 }
 
 func (q *SelectQuery) Exec(ctx context.Context, dest ...interface{}) (res sql.Result, err error) {
 	if q.err != nil {
 		return nil, q.err
 	}
+
+	ctx = q.withOptions(ctx)
+
 	if err := q.beforeAppendModel(ctx, q); err != nil {
 		return nil, err
 	}
 
-	queryBytes, err := q.AppendQuery(q.db.fmter, q.db.makeQueryBytes())
+	queryBytes, err := q.appendCachedQuery(q.db.fmter, q.db.makeQueryBytes())
 	if err != nil {
 		return nil, err
 	}
@@ -841,15 +1515,48 @@ func (q *SelectQuery) Exec(ctx context.Context, dest ...interface{}) (res sql.Re
 	return res, nil
 }
 
+// Scan executes the query and scans the result into the model or dest.
+// Multiple scalar destinations are scanned positionally from the first row,
+// one column per destination, for example:
+//
+//    var id int64
+//    var name string
+//    err := db.NewSelect().Column("id", "name").Table("users").Limit(1).Scan(ctx, &id, &name)
+//
+// Scan returns an error when the number of columns does not match the number
+// of destinations. Multiple slice destinations are filled with one element
+// per row instead.
+//
+// When dest is a channel, rows are scanned and sent one at a time and the
+// channel is closed when Scan returns:
+//
+//    ch := make(chan User)
+//    go func() {
+//        err = db.NewSelect().Model((*User)(nil)).Scan(ctx, ch)
+//    }()
+//    for user := range ch {
+//        ...
+//    }
+//
+// Sending stops with ctx.Err() when the context is canceled.
 func (q *SelectQuery) Scan(ctx context.Context, dest ...interface{}) error {
 	if q.err != nil {
+		closeChanDest(dest)
 		return q.err
 	}
 
+	ctx = q.withOptions(ctx)
+
 	model, err := q.getModel(dest)
 	if err != nil {
+		closeChanDest(dest)
 		return err
 	}
+	defer closeChanModel(model)
+
+	if _, ok := model.(*chanModel); ok && q.tableModel != nil && len(q.tableModel.getJoins()) > 0 {
+		return errors.New("bun: Relation is not supported when scanning into a channel")
+	}
 
 	if q.table != nil {
 		if err := q.beforeSelectHook(ctx); err != nil {
@@ -861,13 +1568,20 @@ func (q *SelectQuery) Scan(ctx context.Context, dest ...interface{}) error {
 		return err
 	}
 
-	queryBytes, err := q.AppendQuery(q.db.fmter, q.db.makeQueryBytes())
+	queryBytes, err := q.appendCachedQuery(q.db.fmter, q.db.makeQueryBytes())
 	if err != nil {
 		return err
 	}
 
 	query := internal.String(queryBytes)
 
+	if q.withTotalCount {
+		q.totalCount = 0
+		if err := setTotalCountDest(model, &q.totalCount); err != nil {
+			return err
+		}
+	}
+
 	res, err := q.scan(ctx, q, query, model, true)
 	if err != nil {
 		return err
@@ -913,6 +1627,8 @@ func (q *SelectQuery) Count(ctx context.Context) (int, error) {
 		return 0, q.err
 	}
 
+	ctx = q.withOptions(ctx)
+
 	qq := countQuery{q}
 
 	queryBytes, err := qq.AppendQuery(q.db.fmter, nil)
@@ -924,7 +1640,7 @@ func (q *SelectQuery) Count(ctx context.Context) (int, error) {
 	ctx, event := q.db.beforeQuery(ctx, qq, query, nil, query, q.model)
 
 	var num int
-	err = q.conn.QueryRowContext(ctx, query).Scan(&num)
+	err = q.queryConn().QueryRowContext(ctx, query).Scan(&num)
 
 	q.db.afterQuery(ctx, event, nil, err)
 
@@ -998,6 +1714,8 @@ func (q *SelectQuery) Exists(ctx context.Context) (bool, error) {
 		return false, q.err
 	}
 
+	ctx = q.withOptions(ctx)
+
 	if q.hasFeature(feature.SelectExists) {
 		return q.selectExists(ctx)
 	}
@@ -1016,7 +1734,7 @@ func (q *SelectQuery) selectExists(ctx context.Context) (bool, error) {
 	ctx, event := q.db.beforeQuery(ctx, qq, query, nil, query, q.model)
 
 	var exists bool
-	err = q.conn.QueryRowContext(ctx, query).Scan(&exists)
+	err = q.queryConn().QueryRowContext(ctx, query).Scan(&exists)
 
 	q.db.afterQuery(ctx, event, nil, err)
 
@@ -1059,6 +1777,12 @@ func (q *SelectQuery) String() string {
 	return string(buf)
 }
 
+// PrettyString is like String, but starts every top-level clause on a new line.
+// It is meant for logging and tests and does not change the executed query.
+func (q *SelectQuery) PrettyString() string {
+	return prettyQuery(q.String())
+}
+
 //------------------------------------------------------------------------------
 
 func (q *SelectQuery) QueryBuilder() QueryBuilder {
@@ -3,8 +3,11 @@ package bun
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/uptrace/bun/dialect/feature"
 	"github.com/uptrace/bun/dialect/sqltype"
@@ -22,6 +25,7 @@ type CreateTableQuery struct {
 	fks         []schema.QueryWithArgs
 	partitionBy schema.QueryWithArgs
 	tablespace  schema.QueryWithArgs
+	onCommit    string
 }
 
 var _ Query = (*CreateTableQuery)(nil)
@@ -102,6 +106,18 @@ func (q *CreateTableQuery) TableSpace(tablespace string) *CreateTableQuery {
 	return q
 }
 
+// OnCommit sets the PostgreSQL action applied to a Temp table at the end
+// of the transaction: "DROP", "DELETE ROWS", or "PRESERVE ROWS".
+func (q *CreateTableQuery) OnCommit(action string) *CreateTableQuery {
+	switch action = strings.ToUpper(action); action {
+	case "DROP", "DELETE ROWS", "PRESERVE ROWS":
+		q.onCommit = action
+	default:
+		q.setErr(fmt.Errorf("bun: unsupported ON COMMIT action %q", action))
+	}
+	return q
+}
+
 func (q *CreateTableQuery) WithForeignKeys() *CreateTableQuery {
 	for _, relation := range q.tableModel.Table().Relations {
 		if relation.Type == schema.ManyToManyRelation ||
@@ -111,7 +127,7 @@ func (q *CreateTableQuery) WithForeignKeys() *CreateTableQuery {
 
 		q = q.ForeignKey("(?) REFERENCES ? (?) ? ?",
 			Safe(appendColumns(nil, "", relation.BaseFields)),
-			relation.JoinTable.SQLName,
+			Safe(q.appendTableName(q.db.fmter, nil, relation.JoinTable, relation.JoinTable.SQLName)),
 			Safe(appendColumns(nil, "", relation.JoinFields)),
 			Safe(relation.OnUpdate),
 			Safe(relation.OnDelete),
@@ -149,6 +165,12 @@ func (q *CreateTableQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []by
 
 	b = append(b, " ("...)
 
+	features := fmter.Dialect().Features()
+	// SQLite only accepts AUTOINCREMENT on an INTEGER PRIMARY KEY column,
+	// so the primary key is declared inline instead of as a table constraint.
+	inlinePK := features.Has(feature.AutoIncrementPK) &&
+		len(q.table.PKs) == 1 && q.table.PKs[0].Identity
+
 	for i, field := range q.table.Fields {
 		if i > 0 {
 			b = append(b, ", "...)
@@ -160,19 +182,24 @@ func (q *CreateTableQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []by
 		if field.NotNull {
 			b = append(b, " NOT NULL"...)
 		}
-		if field.AutoIncrement {
+
+		switch {
+		case field.Identity && features.Has(feature.GeneratedIdentity):
+			if field.IdentityAlways {
+				b = append(b, " GENERATED ALWAYS AS IDENTITY"...)
+			} else {
+				b = append(b, " GENERATED BY DEFAULT AS IDENTITY"...)
+			}
+		case field.Identity && inlinePK && field.IsPK:
+			b = append(b, " PRIMARY KEY AUTOINCREMENT"...)
+		case field.AutoIncrement || field.Identity:
 			switch {
-			case fmter.Dialect().Features().Has(feature.AutoIncrement):
+			case features.Has(feature.AutoIncrement):
 				b = append(b, " AUTO_INCREMENT"...)
-			case fmter.Dialect().Features().Has(feature.Identity):
+			case features.Has(feature.Identity):
 				b = append(b, " IDENTITY"...)
 			}
 		}
-		if field.Identity {
-			if fmter.Dialect().Features().Has(feature.GeneratedIdentity) {
-				b = append(b, " GENERATED BY DEFAULT AS IDENTITY"...)
-			}
-		}
 		if field.SQLDefault != "" {
 			b = append(b, " DEFAULT "...)
 			b = append(b, field.SQLDefault...)
@@ -192,7 +219,9 @@ func (q *CreateTableQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []by
 		}
 	}
 
-	b = q.appendPKConstraint(b, q.table.PKs)
+	if !inlinePK {
+		b = q.appendPKConstraint(b, q.table.PKs)
+	}
 	b = q.appendUniqueConstraints(fmter, b)
 	b, err = q.appendFKConstraints(fmter, b)
 	if err != nil {
@@ -209,6 +238,17 @@ func (q *CreateTableQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []by
 		}
 	}
 
+	if q.onCommit != "" {
+		if !q.temp {
+			return nil, errors.New("bun: OnCommit requires a Temp table")
+		}
+		if !fmter.HasFeature(feature.TempTableOnCommit) {
+			return nil, fmt.Errorf("bun: %s does not support ON COMMIT", fmter.Dialect().Name())
+		}
+		b = append(b, " ON COMMIT "...)
+		b = append(b, q.onCommit...)
+	}
+
 	if !q.tablespace.IsZero() {
 		b = append(b, " TABLESPACE "...)
 		b, err = q.tablespace.AppendQuery(fmter, b)
@@ -0,0 +1,147 @@
+package bun
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/uptrace/bun/dialect/feature"
+	"github.com/uptrace/bun/internal"
+	"github.com/uptrace/bun/schema"
+)
+
+// CreateTableAsQuery builds CREATE TABLE ... AS SELECT, for example:
+//
+//    db.NewCreateTableAs("snapshot", db.NewSelect().Model((*Book)(nil)))
+type CreateTableAsQuery struct {
+	baseQuery
+
+	query       schema.QueryAppender
+	temp        bool
+	ifNotExists bool
+	noData      bool
+}
+
+var _ Query = (*CreateTableAsQuery)(nil)
+
+func NewCreateTableAsQuery(db *DB) *CreateTableAsQuery {
+	q := &CreateTableAsQuery{
+		baseQuery: baseQuery{
+			db:   db,
+			conn: db.DB,
+		},
+	}
+	return q
+}
+
+func (q *CreateTableAsQuery) Conn(db IConn) *CreateTableAsQuery {
+	q.setConn(db)
+	return q
+}
+
+//------------------------------------------------------------------------------
+
+func (q *CreateTableAsQuery) Table(tables ...string) *CreateTableAsQuery {
+	for _, table := range tables {
+		q.addTable(schema.UnsafeIdent(table))
+	}
+	return q
+}
+
+func (q *CreateTableAsQuery) TableExpr(query string, args ...interface{}) *CreateTableAsQuery {
+	q.addTable(schema.SafeQuery(query, args))
+	return q
+}
+
+// As sets the query that produces the table rows.
+func (q *CreateTableAsQuery) As(query schema.QueryAppender) *CreateTableAsQuery {
+	q.query = query
+	return q
+}
+
+//------------------------------------------------------------------------------
+
+func (q *CreateTableAsQuery) Temporary() *CreateTableAsQuery {
+	q.temp = true
+	return q
+}
+
+func (q *CreateTableAsQuery) IfNotExists() *CreateTableAsQuery {
+	q.ifNotExists = true
+	return q
+}
+
+// WithNoData creates the table without copying the rows.
+// Only PostgreSQL supports it.
+func (q *CreateTableAsQuery) WithNoData() *CreateTableAsQuery {
+	q.noData = true
+	return q
+}
+
+//------------------------------------------------------------------------------
+
+func (q *CreateTableAsQuery) Operation() string {
+	return "CREATE TABLE"
+}
+
+func (q *CreateTableAsQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte, err error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	if q.query == nil {
+		return nil, errors.New("bun: CreateTableAsQuery requires a query")
+	}
+
+	if !fmter.HasFeature(feature.CreateTableAs) {
+		return nil, fmt.Errorf("bun: %s does not support CREATE TABLE AS", fmter.Dialect().Name())
+	}
+	if q.noData && !fmter.HasFeature(feature.CreateTableAsNoData) {
+		return nil, fmt.Errorf("bun: %s does not support WITH NO DATA", fmter.Dialect().Name())
+	}
+
+	b = append(b, "CREATE "...)
+	if q.temp {
+		b = append(b, "TEMPORARY "...)
+	}
+	b = append(b, "TABLE "...)
+	if q.ifNotExists {
+		b = append(b, "IF NOT EXISTS "...)
+	}
+
+	b, err = q.appendFirstTable(fmter, b)
+	if err != nil {
+		return nil, err
+	}
+
+	b = append(b, " AS "...)
+
+	b, err = q.query.AppendQuery(fmter, b)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.noData {
+		b = append(b, " WITH NO DATA"...)
+	}
+
+	return b, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (q *CreateTableAsQuery) Exec(ctx context.Context, dest ...interface{}) (sql.Result, error) {
+	queryBytes, err := q.AppendQuery(q.db.fmter, q.db.makeQueryBytes())
+	if err != nil {
+		return nil, err
+	}
+
+	query := internal.String(queryBytes)
+
+	res, err := q.exec(ctx, q, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -5,10 +5,13 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
-	"github.com/uptrace/bun/dialect"
 
+	"github.com/uptrace/bun/dialect"
 	"github.com/uptrace/bun/dialect/feature"
+	"github.com/uptrace/bun/extra/bunjson"
 	"github.com/uptrace/bun/internal"
 	"github.com/uptrace/bun/schema"
 )
@@ -20,7 +23,8 @@ type UpdateQuery struct {
 	setQuery
 	idxHintsQuery
 
-	omitZero bool
+	omitZero         bool
+	returningChanged bool
 }
 
 var _ Query = (*UpdateQuery)(nil)
@@ -29,8 +33,9 @@ func NewUpdateQuery(db *DB) *UpdateQuery {
 	q := &UpdateQuery{
 		whereBaseQuery: whereBaseQuery{
 			baseQuery: baseQuery{
-				db:   db,
-				conn: db.DB,
+				db:    db,
+				conn:  db.DB,
+				flags: db.queryFlags(),
 			},
 		},
 	}
@@ -59,6 +64,13 @@ func (q *UpdateQuery) With(name string, query schema.QueryAppender) *UpdateQuery
 
 //------------------------------------------------------------------------------
 
+// Only adds the ONLY keyword before the table name so PostgreSQL does not
+// include rows from tables that inherit from it.
+func (q *UpdateQuery) Only() *UpdateQuery {
+	q.flags = q.flags.Set(onlyFlag)
+	return q
+}
+
 func (q *UpdateQuery) Table(tables ...string) *UpdateQuery {
 	for _, table := range tables {
 		q.addTable(schema.UnsafeIdent(table))
@@ -76,6 +88,13 @@ func (q *UpdateQuery) ModelTableExpr(query string, args ...interface{}) *UpdateQ
 	return q
 }
 
+// Schema qualifies unqualified table names with the schema.
+// It overrides the schema set with DB.WithSchema.
+func (q *UpdateQuery) Schema(name string) *UpdateQuery {
+	q.schemaName = name
+	return q
+}
+
 //------------------------------------------------------------------------------
 
 func (q *UpdateQuery) Column(columns ...string) *UpdateQuery {
@@ -91,7 +110,9 @@ func (q *UpdateQuery) ExcludeColumn(columns ...string) *UpdateQuery {
 }
 
 func (q *UpdateQuery) Set(query string, args ...interface{}) *UpdateQuery {
-	q.addSet(schema.SafeQuery(query, args))
+	if set := schema.SafeQuery(query, args); q.checkSafeQuery(set) {
+		q.addSet(set)
+	}
 	return q
 }
 
@@ -99,7 +120,53 @@ func (q *UpdateQuery) SetColumn(column string, query string, args ...interface{}
 	if q.db.HasFeature(feature.UpdateMultiTable) {
 		column = q.table.Alias + "." + column
 	}
-	q.addSet(schema.SafeQuery(column+" = "+query, args))
+	if set := schema.SafeQuery(column+" = "+query, args); q.checkSafeQuery(set) {
+		q.addSet(set)
+	}
+	return q
+}
+
+// SetQuery sets the column to the result of the subquery, for example,
+// a correlated subquery that references the updated table:
+//
+//    SET total = (SELECT sum(amount) FROM items WHERE items.order_id = "order".id)
+func (q *UpdateQuery) SetQuery(column string, query schema.QueryAppender) *UpdateQuery {
+	if q.db.HasFeature(feature.UpdateMultiTable) && q.table != nil {
+		column = q.table.Alias + "." + column
+	}
+	q.addSet(schema.SafeQuery(column+" = (?)", []interface{}{query}))
+	return q
+}
+
+// SetJSON updates the value at the dot-separated path of the JSON column
+// without rewriting the rest of the document, for example:
+//
+//    db.NewUpdate().Model(user).SetJSON("data", "settings.theme", "dark").WherePK()
+//
+// The value is marshaled to JSON. Numeric path elements index arrays.
+func (q *UpdateQuery) SetJSON(column, path string, value interface{}) *UpdateQuery {
+	if path == "" {
+		q.setErr(errors.New("bun: SetJSON requires a path"))
+		return q
+	}
+	if _, ok := q.db.Dialect().(schema.JSONSetAppender); !ok {
+		q.setErr(fmt.Errorf("bun: %s does not support SetJSON", q.db.Dialect().Name()))
+		return q
+	}
+
+	data, err := bunjson.Marshal(value)
+	if err != nil {
+		q.setErr(err)
+		return q
+	}
+
+	if q.db.HasFeature(feature.UpdateMultiTable) && q.table != nil {
+		column = q.table.Alias + "." + column
+	}
+	q.addSet(schema.SafeQuery("? = ?", []interface{}{
+		Ident(column),
+		&jsonSet{column: column, path: strings.Split(path, "."), value: string(data)},
+	}))
 	return q
 }
 
@@ -125,6 +192,14 @@ func (q *UpdateQuery) WherePK(cols ...string) *UpdateQuery {
 	return q
 }
 
+// WherePKSorted is like WherePK, but sorts primary key values of a slice-based
+// model so the same set of rows always produces the same query.
+func (q *UpdateQuery) WherePKSorted(cols ...string) *UpdateQuery {
+	q.flags = q.flags.Set(wherePKSortedFlag)
+	q.addWhereCols(cols)
+	return q
+}
+
 func (q *UpdateQuery) Where(query string, args ...interface{}) *UpdateQuery {
 	q.addWhere(schema.SafeQueryWithSep(query, args, " AND "))
 	return q
@@ -135,6 +210,22 @@ func (q *UpdateQuery) WhereOr(query string, args ...interface{}) *UpdateQuery {
 	return q
 }
 
+// WhereIf adds a WHERE condition joined with AND only when cond is true.
+func (q *UpdateQuery) WhereIf(cond bool, query string, args ...interface{}) *UpdateQuery {
+	if cond {
+		q.addWhere(schema.SafeQueryWithSep(query, args, " AND "))
+	}
+	return q
+}
+
+// WhereOrIf adds a WHERE condition joined with OR only when cond is true.
+func (q *UpdateQuery) WhereOrIf(cond bool, query string, args ...interface{}) *UpdateQuery {
+	if cond {
+		q.addWhere(schema.SafeQueryWithSep(query, args, " OR "))
+	}
+	return q
+}
+
 func (q *UpdateQuery) WhereGroup(sep string, fn func(*UpdateQuery) *UpdateQuery) *UpdateQuery {
 	saved := q.where
 	q.where = nil
@@ -149,6 +240,110 @@ func (q *UpdateQuery) WhereGroup(sep string, fn func(*UpdateQuery) *UpdateQuery)
 	return q
 }
 
+// WhereOrQuery appends WHERE conditions of the other query as a parenthesized
+// group that is joined with OR, for example, WHERE (a) OR ((b) AND (c)).
+func (q *UpdateQuery) WhereOrQuery(other *UpdateQuery) *UpdateQuery {
+	q.addWhereQuery(" OR ", &other.whereBaseQuery)
+	return q
+}
+
+// WhereSubAny adds a condition comparing the column with any row
+// of the subquery, for example, WHERE ("price" = ANY (SELECT ...)).
+// SQLite does not support ANY with a subquery.
+func (q *UpdateQuery) WhereSubAny(column, op string, query schema.QueryAppender) *UpdateQuery {
+	q.addWhereSub(column, op, "ANY", query)
+	return q
+}
+
+// WhereSubAll adds a condition comparing the column with all rows
+// of the subquery, for example, WHERE ("price" > ALL (SELECT ...)).
+// SQLite does not support ALL with a subquery.
+func (q *UpdateQuery) WhereSubAll(column, op string, query schema.QueryAppender) *UpdateQuery {
+	q.addWhereSub(column, op, "ALL", query)
+	return q
+}
+
+// WhereInFunc adds a `column IN (...)` condition with values pulled from next
+// until it returns false. The number of values is limited to 10000.
+func (q *UpdateQuery) WhereInFunc(column string, next func() (interface{}, bool)) *UpdateQuery {
+	q.addWhereInFunc(column, next)
+	return q
+}
+
+// WhereInValuesJoin adds a `column IN (...)` condition that PostgreSQL renders
+// as a VALUES constructor, for example, `id IN (VALUES (1), (2), (3))`.
+// PostgreSQL plans it as a hash semi-join, which is usually faster than an IN
+// list with thousands of values; see BenchmarkWhereInValuesJoin. Dialects
+// without feature.InValues use a regular IN list.
+func (q *UpdateQuery) WhereInValuesJoin(column string, values interface{}) *UpdateQuery {
+	q.addWhereInValuesJoin(column, values)
+	return q
+}
+
+// WhereRaw adds the SQL condition verbatim without processing placeholders,
+// which allows operators such as the PostgreSQL jsonb `?` operator:
+//
+//    q.WhereRaw(`attrs ? 'color'`)
+//
+// The condition is not escaped. Never pass user input to WhereRaw.
+func (q *UpdateQuery) WhereRaw(query string) *UpdateQuery {
+	q.addWhereRaw(query)
+	return q
+}
+
+// WhereInChunked adds a `column IN (...)` condition that is split into groups
+// of at most 1000 values joined with OR, for example,
+// `("id" IN (1, ...) OR "id" IN (1001, ...))`. It is meant for databases that
+// limit the number of items in one IN list, for example, Oracle allows 1000.
+// The values are inlined like with In, so it does not reduce the number of
+// query parameters.
+func (q *UpdateQuery) WhereInChunked(column string, values interface{}) *UpdateQuery {
+	q.addWhereInChunked(column, values)
+	return q
+}
+
+// WhereSTDWithin adds a PostGIS condition that matches rows within the distance
+// in meters from the point, for example:
+//
+//    q.WhereSTDWithin("location", -73.9857, 40.7484, 500)
+//
+// The coordinates are longitude and latitude in WGS 84 (SRID 4326), and the
+// column is cast to geography. Only PostgreSQL with PostGIS supports it.
+func (q *UpdateQuery) WhereSTDWithin(column string, lon, lat, meters float64) *UpdateQuery {
+	q.addWhereSTDWithin(column, lon, lat, meters)
+	return q
+}
+
+// WhereTrigramSimilar adds a pg_trgm fuzzy match condition, for example,
+// `"name" % 'jon' AND similarity("name", 'jon') >= 0.5`. The % operator
+// can use a trigram index and matches rows above pg_trgm.similarity_threshold
+// (0.3 by default), so thresholds below it have no effect. Use 0 to rely on
+// the % operator only. Only PostgreSQL with pg_trgm supports it.
+func (q *UpdateQuery) WhereTrigramSimilar(column, query string, threshold float64) *UpdateQuery {
+	q.addWhereTrigramSimilar(column, query, threshold)
+	return q
+}
+
+// WhereCollate adds a comparison that uses the collation, for example,
+// WHERE ("name" = 'foo' COLLATE "C") on PostgreSQL.
+func (q *UpdateQuery) WhereCollate(column, op string, value interface{}, collation string) *UpdateQuery {
+	q.addWhereCollate(column, op, value, collation)
+	return q
+}
+
+// Conditions returns a copy of the WHERE conditions added so far.
+// Groups are represented by items with "(" and ")" separators.
+func (q *UpdateQuery) Conditions() []schema.QueryWithSep {
+	return q.conditions()
+}
+
+// SetConditions replaces the WHERE conditions, for example,
+// with conditions returned by Conditions and then reordered.
+func (q *UpdateQuery) SetConditions(where []schema.QueryWithSep) *UpdateQuery {
+	q.setConditions(where)
+	return q
+}
+
 func (q *UpdateQuery) WhereDeleted() *UpdateQuery {
 	q.whereDeleted()
 	return q
@@ -169,10 +364,32 @@ func (q *UpdateQuery) Returning(query string, args ...interface{}) *UpdateQuery
 	return q
 }
 
+// ReturningAll returns all model columns instead of only the auto-generated ones
+// so values set by triggers or column defaults are scanned back into the model.
+func (q *UpdateQuery) ReturningAll() *UpdateQuery {
+	if q.table == nil {
+		q.setErr(fmt.Errorf("bun: got %T, but ReturningAll requires a struct or slice-based model", q.model))
+		return q
+	}
+	q.addReturningAll(q.table)
+	return q
+}
+
+// ReturningChanged returns only the columns updated by the SET clause,
+// for example, `UPDATE ... SET "name" = 'x', "age" = 1 RETURNING "name", "age"`.
+// Set and SetColumn expressions must start with a plain column name.
+func (q *UpdateQuery) ReturningChanged() *UpdateQuery {
+	q.returningChanged = true
+	return q
+}
+
 func (q *UpdateQuery) hasReturning() bool {
 	if !q.db.features.Has(feature.Returning) {
 		return false
 	}
+	if q.returningChanged {
+		return true
+	}
 	return q.returningQuery.hasReturning()
 }
 
@@ -187,7 +404,7 @@ func (q *UpdateQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte, e
 		return nil, q.err
 	}
 
-	fmter = formatterWithModel(fmter, q)
+	fmter = formatterWithModel(fmter, q.db, q)
 
 	b, err = q.appendWith(fmter, b)
 	if err != nil {
@@ -231,15 +448,106 @@ func (q *UpdateQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte, e
 
 	if q.hasFeature(feature.Returning) && q.hasReturning() {
 		b = append(b, " RETURNING "...)
-		b, err = q.appendReturning(fmter, b)
+		if q.returningChanged {
+			b, err = q.appendReturningChanged(fmter, b)
+		} else {
+			b, err = q.appendReturning(fmter, b)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+// appendReturningChanged appends the columns of the SET clause.
+func (q *UpdateQuery) appendReturningChanged(fmter schema.Formatter, b []byte) (_ []byte, err error) {
+	pos := len(b)
+	appendSep := func() {
+		if len(b) != pos {
+			b = append(b, ", "...)
+		}
+	}
+
+	if len(q.set) > 0 {
+		for _, set := range q.set {
+			column, ok := setColumnName(set.Query)
+			if !ok {
+				return nil, fmt.Errorf("bun: ReturningChanged can't find the column in SET %q", set.Query)
+			}
+			appendSep()
+			b = fmter.AppendIdent(b, column)
+		}
+		return b, nil
+	}
+
+	switch model := q.model.(type) {
+	case *mapModel:
+		keys := make([]string, 0, len(model.m))
+		for k := range model.m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			appendSep()
+			b = fmter.AppendIdent(b, k)
+		}
+	case *structTableModel:
+		fields, err := q.setStructFields(model)
 		if err != nil {
 			return nil, err
 		}
+		for _, f := range fields {
+			appendSep()
+			b = append(b, f.SQLName...)
+		}
+		for _, v := range q.extraValues {
+			appendSep()
+			b = append(b, v.column...)
+		}
+	default:
+		return nil, fmt.Errorf("bun: ReturningChanged does not support %T", q.model)
 	}
 
 	return b, nil
 }
 
+// setColumnName returns the column name from a SET expression like
+// `"alias"."column" = value`.
+func setColumnName(set string) (string, bool) {
+	i := strings.IndexByte(set, '=')
+	if i == -1 {
+		return "", false
+	}
+
+	name := strings.TrimSpace(set[:i])
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.Trim(name, "\"`")
+
+	if name == "" {
+		return "", false
+	}
+	for _, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_':
+		default:
+			return "", false
+		}
+	}
+	return name, true
+}
+
+// AppendQueryArgs is like AppendQuery, but appends placeholders instead of
+// query arguments and returns the arguments separately, for example,
+// to reuse a prepared statement.
+func (q *UpdateQuery) AppendQueryArgs(fmter schema.Formatter, b []byte) ([]byte, []interface{}, error) {
+	return appendQueryArgs(fmter, b, q)
+}
+
 func (q *UpdateQuery) mustAppendSet(fmter schema.Formatter, b []byte) (_ []byte, err error) {
 	b = append(b, " SET "...)
 
@@ -270,26 +578,39 @@ func (q *UpdateQuery) mustAppendSet(fmter schema.Formatter, b []byte) (_ []byte,
 	return b, nil
 }
 
-func (q *UpdateQuery) appendSetStruct(
-	fmter schema.Formatter, b []byte, model *structTableModel,
-) ([]byte, error) {
+// setStructFields returns the model fields that are updated by the SET clause.
+func (q *UpdateQuery) setStructFields(model *structTableModel) ([]*schema.Field, error) {
 	fields, err := q.getDataFields()
 	if err != nil {
 		return nil, err
 	}
 
-	isTemplate := fmter.IsNop()
-	pos := len(b)
+	set := make([]*schema.Field, 0, len(fields))
 	for _, f := range fields {
 		if f.SkipUpdate() {
 			continue
 		}
-
-		app, hasValue := q.modelValues[f.Name]
-
-		if !hasValue && q.omitZero && f.HasZeroValue(model.strct) {
+		if _, hasValue := q.modelValues[f.Name]; !hasValue &&
+			q.omitZero && f.HasZeroValue(model.strct) {
 			continue
 		}
+		set = append(set, f)
+	}
+	return set, nil
+}
+
+func (q *UpdateQuery) appendSetStruct(
+	fmter schema.Formatter, b []byte, model *structTableModel,
+) ([]byte, error) {
+	fields, err := q.setStructFields(model)
+	if err != nil {
+		return nil, err
+	}
+
+	isTemplate := fmter.IsNop()
+	pos := len(b)
+	for _, f := range fields {
+		app, hasValue := q.modelValues[f.Name]
 
 		if len(b) != pos {
 			b = append(b, ", "...)
@@ -404,7 +725,7 @@ func (q *UpdateQuery) updateSliceWhere(fmter schema.Formatter, model *sliceTable
 		if q.hasTableAlias(fmter) {
 			b = append(b, model.table.SQLAlias...)
 		} else {
-			b = append(b, model.table.SQLName...)
+			b = q.appendTableName(fmter, b, model.table, model.table.SQLName)
 		}
 		b = append(b, '.')
 		b = append(b, pk.SQLName...)
@@ -509,6 +830,12 @@ func (q *UpdateQuery) String() string {
 	return string(buf)
 }
 
+// PrettyString is like String, but starts every top-level clause on a new line.
+// It is meant for logging and tests and does not change the executed query.
+func (q *UpdateQuery) PrettyString() string {
+	return prettyQuery(q.String())
+}
+
 //------------------------------------------------------------------------------
 
 func (q *UpdateQuery) QueryBuilder() QueryBuilder {
@@ -583,3 +910,21 @@ func (q *UpdateQuery) ForceIndex(indexes ...string) *UpdateQuery {
 	}
 	return q
 }
+
+//------------------------------------------------------------------------------
+
+type jsonSet struct {
+	column string
+	path   []string
+	value  string
+}
+
+var _ schema.QueryAppender = (*jsonSet)(nil)
+
+func (s *jsonSet) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte, err error) {
+	d, ok := fmter.Dialect().(schema.JSONSetAppender)
+	if !ok {
+		return nil, fmt.Errorf("bun: %s does not support SetJSON", fmter.Dialect().Name())
+	}
+	return d.AppendJSONSet(fmter, b, s.column, s.path, s.value), nil
+}
@@ -116,7 +116,7 @@ func (q *ValuesQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte, e
 		return nil, errNilModel
 	}
 
-	fmter = formatterWithModel(fmter, q)
+	fmter = formatterWithModel(fmter, q.db, q)
 
 	if q.tableModel != nil {
 		fields, err := q.getFields()
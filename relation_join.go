@@ -151,7 +151,8 @@ func (j *relationJoin) m2mQuery(q *SelectQuery) *SelectQuery {
 	//nolint
 	var join []byte
 	join = append(join, "JOIN "...)
-	join = fmter.AppendQuery(join, string(j.Relation.M2MTable.Name))
+	join = q.appendSchema(fmter, join, j.Relation.M2MTable.Name)
+	join = fmter.AppendQuery(join, q.db.addTablePrefix(j.Relation.M2MTable.Name))
 	join = append(join, " AS "...)
 	join = append(join, j.Relation.M2MTable.SQLAlias...)
 	join = append(join, " ON ("...)
@@ -224,9 +225,17 @@ func (j *relationJoin) appendBaseAlias(fmter schema.Formatter, b []byte) []byte
 	return append(b, j.BaseModel.Table().SQLAlias...)
 }
 
-func (j *relationJoin) appendSoftDelete(b []byte, flags internal.Flag) []byte {
+func (j *relationJoin) appendSoftDelete(
+	fmter schema.Formatter, b []byte, flags internal.Flag,
+) []byte {
+	colStart := len(b)
+	b = j.appendAlias(fmter, b)
 	b = append(b, '.')
 	b = append(b, j.JoinModel.Table().SoftDeleteField.SQLName...)
+	if j.JoinModel.Table().SoftDeleteBool {
+		column := append([]byte(nil), b[colStart:]...)
+		return appendSoftDeleteBool(fmter, b[:colStart], column, flags.Has(deletedFlag))
+	}
 	if flags.Has(deletedFlag) {
 		b = append(b, " IS NOT NULL"...)
 	} else {
@@ -250,7 +259,8 @@ func (j *relationJoin) appendHasOneJoin(
 	isSoftDelete := j.JoinModel.Table().SoftDeleteField != nil && !q.flags.Has(allWithDeletedFlag)
 
 	b = append(b, "LEFT JOIN "...)
-	b = fmter.AppendQuery(b, string(j.JoinModel.Table().SQLNameForSelects))
+	joinTable := j.JoinModel.Table()
+	b = q.appendTableName(fmter, b, joinTable, joinTable.SQLNameForSelects)
 	b = append(b, " AS "...)
 	b = j.appendAlias(fmter, b)
 
@@ -273,8 +283,7 @@ func (j *relationJoin) appendHasOneJoin(
 
 	if isSoftDelete {
 		b = append(b, " AND "...)
-		b = j.appendAlias(fmter, b)
-		b = j.appendSoftDelete(b, q.flags)
+		b = j.appendSoftDelete(fmter, b, q.flags)
 	}
 
 	return b, nil
@@ -1,6 +1,8 @@
 package schema
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
@@ -51,6 +53,22 @@ func Append(fmter Formatter, b []byte, v interface{}) []byte {
 
 //------------------------------------------------------------------------------
 
+// EmptyInPolicy controls how In renders an empty slice, see
+// Formatter.WithEmptyInPolicy.
+type EmptyInPolicy uint8
+
+const (
+	// EmptyInAsIs renders the empty list as is, for example, `id IN ()`.
+	EmptyInAsIs EmptyInPolicy = iota
+	// EmptyInError renders an error instead of the list, so the query fails.
+	EmptyInError
+	// EmptyInFalseLiteral renders the empty list as NULL, so `id IN (?)`
+	// is never true and matches no rows. `id NOT IN (?)` can't be rendered
+	// that way, because it would not match any rows either, so it fails
+	// with an error.
+	EmptyInFalseLiteral
+)
+
 func In(slice interface{}) QueryAppender {
 	v := reflect.ValueOf(slice)
 	if v.Kind() != reflect.Slice {
@@ -74,9 +92,42 @@ func (in *inValues) AppendQuery(fmter Formatter, b []byte) (_ []byte, err error)
 	if in.err != nil {
 		return nil, in.err
 	}
+	if in.slice.Len() == 0 {
+		switch fmter.EmptyInPolicy() {
+		case EmptyInError:
+			return nil, errors.New("bun: empty IN list")
+		case EmptyInFalseLiteral:
+			if isNotIn(b) {
+				return nil, errors.New("bun: empty NOT IN list")
+			}
+			return dialect.AppendNull(b), nil
+		}
+	}
 	return appendIn(fmter, b, in.slice), nil
 }
 
+// isNotIn reports whether b ends with `NOT IN (`.
+func isNotIn(b []byte) bool {
+	b = bytes.TrimRight(b, " \t\r\n")
+	if len(b) == 0 || b[len(b)-1] != '(' {
+		return false
+	}
+	b = bytes.TrimRight(b[:len(b)-1], " \t\r\n")
+	if len(b) < 3 || !bytes.EqualFold(b[len(b)-2:], []byte("IN")) || !isSpace(b[len(b)-3]) {
+		return false
+	}
+	b = bytes.TrimRight(b[:len(b)-2], " \t\r\n")
+	if len(b) < 3 || !bytes.EqualFold(b[len(b)-3:], []byte("NOT")) {
+		return false
+	}
+	return len(b) == 3 || isSpace(b[len(b)-4]) || b[len(b)-4] == ')'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+
 func appendIn(fmter Formatter, b []byte, slice reflect.Value) []byte {
 	sliceLen := slice.Len()
 	for i := 0; i < sliceLen; i++ {
@@ -2,6 +2,7 @@ package schema
 
 import (
 	"database/sql/driver"
+	"encoding"
 	"fmt"
 	"net"
 	"reflect"
@@ -57,6 +58,13 @@ func FieldAppender(dialect Dialect, field *Field) AppenderFunc {
 	if field.Tag.HasOption("msgpack") {
 		return appendMsgpack
 	}
+	if unit, ok := unixTimeUnit(field); ok {
+		fn := unixTimeAppender(unit)
+		if field.IsPtr {
+			return PtrAppender(fn)
+		}
+		return fn
+	}
 
 	fieldType := field.StructField.Type
 
@@ -73,6 +81,12 @@ func FieldAppender(dialect Dialect, field *Field) AppenderFunc {
 		}
 
 		return AppendJSONValue
+	case "":
+		// Fields without an explicit SQL type, for example, `type:inet`,
+		// are stored as JSON even if the type has a text or binary marshaler.
+		if valueMarshaler(field.IndirectType) != noMarshaler {
+			return AppendJSONValue
+		}
 	}
 
 	return Appender(dialect, fieldType)
@@ -130,6 +144,9 @@ func appender(dialect Dialect, typ reflect.Type) AppenderFunc {
 		if ptr.Implements(driverValuerType) {
 			return addrAppender(appendDriverValue)
 		}
+		if fn := marshalerAppender(typ); fn != nil {
+			return fn
+		}
 	}
 
 	switch kind {
@@ -155,6 +172,94 @@ func appender(dialect Dialect, typ reflect.Type) AppenderFunc {
 	return appenders[typ.Kind()]
 }
 
+type marshalerKind uint8
+
+const (
+	noMarshaler marshalerKind = iota
+	textMarshaler
+	binaryMarshaler
+)
+
+// valueMarshaler reports which marshaler appends values of types that are
+// scanned with UnmarshalText or UnmarshalBinary so the values round-trip.
+func valueMarshaler(typ reflect.Type) marshalerKind {
+	if !usesMarshaler(typ) {
+		return noMarshaler
+	}
+
+	ptr := reflect.PtrTo(typ)
+	if typ.Implements(scannerType) || ptr.Implements(scannerType) {
+		return noMarshaler
+	}
+
+	if typ.Implements(textUnmarshalerType) || ptr.Implements(textUnmarshalerType) {
+		if typ.Implements(textMarshalerType) || ptr.Implements(textMarshalerType) {
+			return textMarshaler
+		}
+		return noMarshaler
+	}
+
+	if typ.Implements(binaryUnmarshalerType) || ptr.Implements(binaryUnmarshalerType) {
+		if typ.Implements(binaryMarshalerType) || ptr.Implements(binaryMarshalerType) {
+			return binaryMarshaler
+		}
+	}
+
+	return noMarshaler
+}
+
+// usesMarshaler reports whether values of the type are appended and scanned
+// with the encoding marshalers. Named basic types, for example, a log level
+// that is an int8 with MarshalText, keep the representation of their kind
+// so they match the column type. time.Time has its own appender and scanner.
+func usesMarshaler(typ reflect.Type) bool {
+	if typ == timeType {
+		return false
+	}
+	switch typ.Kind() {
+	case reflect.Struct, reflect.Array:
+		return true
+	}
+	return false
+}
+
+// marshalerAppender returns an appender that matches the scanner, for example,
+// netip.Addr is appended as '1.2.3.4' instead of JSON.
+func marshalerAppender(typ reflect.Type) AppenderFunc {
+	var fn AppenderFunc
+	var iface reflect.Type
+
+	switch valueMarshaler(typ) {
+	case textMarshaler:
+		fn, iface = appendTextMarshalerValue, textMarshalerType
+	case binaryMarshaler:
+		fn, iface = appendBinaryMarshalerValue, binaryMarshalerType
+	default:
+		return nil
+	}
+
+	if typ.Implements(iface) {
+		return fn
+	}
+	return addrAppender(fn)
+}
+
+func appendTextMarshalerValue(fmter Formatter, b []byte, v reflect.Value) []byte {
+	text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return dialect.AppendError(b, err)
+	}
+	return fmter.Dialect().AppendString(b, internal.String(text))
+}
+
+func appendBinaryMarshalerValue(fmter Formatter, b []byte, v reflect.Value) []byte {
+	data, err := v.Interface().(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return dialect.AppendError(b, err)
+	}
+	return fmter.Dialect().AppendBytes(b, data)
+}
+
 func ifaceAppenderFunc(fmter Formatter, b []byte, v reflect.Value) []byte {
 	if v.IsNil() {
 		return dialect.AppendNull(b)
@@ -247,6 +352,18 @@ func appendTimeValue(fmter Formatter, b []byte, v reflect.Value) []byte {
 	return fmter.Dialect().AppendTime(b, tm)
 }
 
+// unixTimeAppender appends time.Time as an integer Unix timestamp.
+// The zero time is appended as 0.
+func unixTimeAppender(unit time.Duration) AppenderFunc {
+	return func(fmter Formatter, b []byte, v reflect.Value) []byte {
+		tm := v.Interface().(time.Time)
+		if tm.IsZero() {
+			return append(b, '0')
+		}
+		return strconv.AppendInt(b, timeToUnix(tm, unit), 10)
+	}
+}
+
 func appendIPValue(fmter Formatter, b []byte, v reflect.Value) []byte {
 	ip := v.Interface().(net.IP)
 	return fmter.Dialect().AppendString(b, ip.String())
@@ -12,6 +12,16 @@ import (
 	"github.com/uptrace/bun/internal/parser"
 )
 
+// Dialect describes the SQL syntax of a database. Besides the bundled dialects,
+// bun.NewDB accepts custom implementations for databases that speak a variant
+// of a supported protocol, for example, CockroachDB or YugabyteDB. Custom
+// dialects usually embed BaseDialect, report dialect.Invalid from Name, and
+// enable the syntax they support with Features, for example,
+// feature.Returning or feature.InsertOnConflict. Syntax that the bundled
+// dialects render differently is provided by optional interfaces:
+// PlaceholderAppender, NoLimitAppender, JSONSetAppender,
+// CurrentTimestampAppender, IdentLimiter, and IndexInspector.
+// Only MySQL index hints are still gated by Name.
 type Dialect interface {
 	Init(db *sql.DB)
 
@@ -31,6 +41,60 @@ type Dialect interface {
 	AppendJSON(b, jsonb []byte) []byte
 }
 
+// PlaceholderAppender is implemented by dialects that use their own style of
+// bind placeholders, for example, $1 or :1. It is used by Formatter.WithBindArgs;
+// by default, dialects use '?'.
+type PlaceholderAppender interface {
+	AppendPlaceholder(b []byte, n int) []byte
+}
+
+// NoLimitAppender is implemented by dialects that don't support OFFSET without
+// LIMIT. AppendNoLimit appends a LIMIT clause that does not restrict the number
+// of rows, for example, " LIMIT -1".
+type NoLimitAppender interface {
+	AppendNoLimit(b []byte) []byte
+}
+
+// JSONSetAppender is implemented by dialects that can update a value inside
+// of a JSON document. It is used by UpdateQuery.SetJSON to append an expression
+// that sets the value at the path of the column to the JSON encoded value.
+type JSONSetAppender interface {
+	AppendJSONSet(fmter Formatter, b []byte, column string, path []string, value string) []byte
+}
+
+// CurrentTimestampAppender is implemented by dialects whose CURRENT_TIMESTAMP
+// is not in UTC, for example, in the session time zone. AppendCurrentTimestamp
+// appends an expression that returns the current time in UTC.
+type CurrentTimestampAppender interface {
+	AppendCurrentTimestamp(b []byte) []byte
+}
+
+// IdentLimiter is implemented by dialects that limit the length of identifiers.
+// MaxIdentLen returns the maximum length in bytes. It is used to shorten
+// generated names, for example, index names of CreateIndexQuery.
+type IdentLimiter interface {
+	MaxIdentLen() int
+}
+
+// IndexInfo describes an index that exists in the database.
+type IndexInfo struct {
+	Name string
+	// Columns contains column names and, on PostgreSQL,
+	// expressions of expression indexes, for example, lower(name).
+	// SQLite and MySQL don't return expressions, so such columns are empty.
+	Columns []string
+	Unique  bool
+}
+
+// IndexInspector is implemented by dialects that can list indexes of a table.
+type IndexInspector interface {
+	// IndexesQuery returns a query that accepts a table name as the only argument
+	// and selects index name, unique flag, and column name for each indexed column
+	// ordered by index name and column position. The column name can be NULL
+	// for expression columns.
+	IndexesQuery() string
+}
+
 //------------------------------------------------------------------------------
 
 type BaseDialect struct{}
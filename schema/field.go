@@ -28,11 +28,12 @@ type Field struct {
 	OnDelete string
 	OnUpdate string
 
-	IsPK          bool
-	NotNull       bool
-	NullZero      bool
-	AutoIncrement bool
-	Identity      bool
+	IsPK           bool
+	NotNull        bool
+	NullZero       bool
+	AutoIncrement  bool
+	Identity       bool
+	IdentityAlways bool
 
 	Append AppenderFunc
 	Scan   ScannerFunc
@@ -125,6 +126,13 @@ func (f *Field) SkipUpdate() bool {
 	return f.Tag.HasOption("skipupdate")
 }
 
+// IsUnixTime reports whether the time.Time field is stored as a Unix
+// timestamp in an integer column, see the unixtime and unixmilli options.
+func (f *Field) IsUnixTime() bool {
+	_, ok := unixTimeUnit(f)
+	return ok
+}
+
 func indexEqual(ind1, ind2 []int) bool {
 	if len(ind1) != len(ind2) {
 		return false
@@ -16,8 +16,12 @@ var nopFormatter = Formatter{
 }
 
 type Formatter struct {
-	dialect Dialect
-	args    *namedArgList
+	dialect  Dialect
+	args     *namedArgList
+	bindArgs *[]interface{}
+	recorder *ArgRecorder
+
+	emptyInPolicy EmptyInPolicy
 }
 
 func NewFormatter(dialect Dialect) Formatter {
@@ -30,8 +34,12 @@ func NewNopFormatter() Formatter {
 	return nopFormatter
 }
 
+// IsNop reports whether the formatter leaves placeholders as is.
+// Custom dialects may also report dialect.Invalid, so the check is done
+// on the dialect type instead of the name.
 func (f Formatter) IsNop() bool {
-	return f.dialect.Name() == dialect.Invalid
+	_, ok := f.dialect.(*nopDialect)
+	return ok
 }
 
 func (f Formatter) Dialect() Dialect {
@@ -60,18 +68,72 @@ func (f Formatter) HasFeature(feature feature.Feature) bool {
 
 func (f Formatter) WithArg(arg NamedArgAppender) Formatter {
 	return Formatter{
-		dialect: f.dialect,
-		args:    f.args.WithArg(arg),
+		dialect:  f.dialect,
+		args:     f.args.WithArg(arg),
+		bindArgs: f.bindArgs,
+		recorder: f.recorder,
+
+		emptyInPolicy: f.emptyInPolicy,
 	}
 }
 
 func (f Formatter) WithNamedArg(name string, value interface{}) Formatter {
 	return Formatter{
-		dialect: f.dialect,
-		args:    f.args.WithArg(&namedArg{name: name, value: value}),
+		dialect:  f.dialect,
+		args:     f.args.WithArg(&namedArg{name: name, value: value}),
+		bindArgs: f.bindArgs,
+		recorder: f.recorder,
+
+		emptyInPolicy: f.emptyInPolicy,
+	}
+}
+
+// WithBindArgs returns a formatter that appends dialect placeholders
+// instead of inlining query arguments and collects the arguments into args.
+// Values that come from the model, for example, inserted columns, are still inlined.
+func (f Formatter) WithBindArgs(args *[]interface{}) Formatter {
+	return Formatter{
+		dialect:  f.dialect,
+		args:     f.args,
+		bindArgs: args,
+		recorder: f.recorder,
+
+		emptyInPolicy: f.emptyInPolicy,
+	}
+}
+
+// WithArgRecorder returns a formatter that leaves positional query arguments
+// out of the query and records where they belong, so the query can be reused
+// as a template for other argument values. Query appenders, for example,
+// Ident, are still appended.
+func (f Formatter) WithArgRecorder(r *ArgRecorder) Formatter {
+	return Formatter{
+		dialect:  f.dialect,
+		args:     f.args,
+		bindArgs: f.bindArgs,
+		recorder: r,
+
+		emptyInPolicy: f.emptyInPolicy,
+	}
+}
+
+// WithEmptyInPolicy returns a formatter that renders In with an empty slice
+// according to the policy.
+func (f Formatter) WithEmptyInPolicy(policy EmptyInPolicy) Formatter {
+	return Formatter{
+		dialect:  f.dialect,
+		args:     f.args,
+		bindArgs: f.bindArgs,
+		recorder: f.recorder,
+
+		emptyInPolicy: policy,
 	}
 }
 
+func (f Formatter) EmptyInPolicy() EmptyInPolicy {
+	return f.emptyInPolicy
+}
+
 func (f Formatter) FormatQuery(query string, args ...interface{}) string {
 	if f.IsNop() || (args == nil && f.args == nil) || strings.IndexByte(query, '?') == -1 {
 		return query
@@ -122,6 +184,9 @@ func (f Formatter) append(dst []byte, p *parser.Parser, args []interface{}) []by
 					goto restore_arg
 				}
 
+				if f.recorder.record(dst, &args[idx]) {
+					continue
+				}
 				dst = f.appendArg(dst, args[idx])
 				continue
 			}
@@ -149,10 +214,13 @@ func (f Formatter) append(dst []byte, p *parser.Parser, args []interface{}) []by
 			continue
 		}
 
-		arg := args[argIndex]
+		arg := &args[argIndex]
 		argIndex++
 
-		dst = f.appendArg(dst, arg)
+		if f.recorder.record(dst, arg) {
+			continue
+		}
+		dst = f.appendArg(dst, *arg)
 	}
 
 	return dst
@@ -167,10 +235,53 @@ func (f Formatter) appendArg(b []byte, arg interface{}) []byte {
 		}
 		return bb
 	default:
+		if f.bindArgs != nil {
+			*f.bindArgs = append(*f.bindArgs, arg)
+			return f.appendPlaceholder(b, len(*f.bindArgs))
+		}
 		return Append(f, b, arg)
 	}
 }
 
+func (f Formatter) appendPlaceholder(b []byte, n int) []byte {
+	if d, ok := f.dialect.(PlaceholderAppender); ok {
+		return d.AppendPlaceholder(b, n)
+	}
+	return append(b, '?')
+}
+
+//------------------------------------------------------------------------------
+
+// ArgRecorder records the query arguments left out of a query formatted
+// by a formatter returned by Formatter.WithArgRecorder.
+type ArgRecorder struct {
+	// Args are the addresses of the arguments in the order they are left out.
+	Args []*interface{}
+	// Offsets are the positions in the query where the arguments belong.
+	Offsets []int
+	// Inline reports whether values of struct fields were appended
+	// to the query, for example, by ?id with a struct argument or model.
+	Inline bool
+}
+
+func (r *ArgRecorder) record(b []byte, arg *interface{}) bool {
+	if r == nil {
+		return false
+	}
+	if _, ok := (*arg).(QueryAppender); ok {
+		return false
+	}
+	r.Args = append(r.Args, arg)
+	r.Offsets = append(r.Offsets, len(b))
+	return true
+}
+
+func (r *ArgRecorder) inline() {
+	if r != nil {
+		r.Inline = true
+	}
+}
+
 //------------------------------------------------------------------------------
 
 type NamedArgAppender interface {
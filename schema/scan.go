@@ -3,22 +3,43 @@ package schema
 import (
 	"bytes"
 	"database/sql"
+	"encoding"
 	"fmt"
 	"net"
 	"reflect"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
 
 	"github.com/uptrace/bun/dialect/sqltype"
 	"github.com/uptrace/bun/extra/bunjson"
 	"github.com/uptrace/bun/internal"
 )
 
-var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+var (
+	scannerType           = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	textUnmarshalerType   = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+	textMarshalerType     = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	binaryMarshalerType   = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+)
+
+// msgpackMaxLen is accessed atomically because it can be changed
+// while other goroutines scan rows.
+var msgpackMaxLen int64
+
+// SetMsgpackMaxLen limits the number of elements in msgpack arrays and maps
+// decoded from columns with the `msgpack` tag option. Payloads that declare
+// a longer array or map are rejected before any allocation happens.
+// Zero, which is the default, disables the check.
+func SetMsgpackMaxLen(n int) {
+	atomic.StoreInt64(&msgpackMaxLen, int64(n))
+}
 
 type ScannerFunc func(dest reflect.Value, src interface{}) error
 
@@ -55,22 +76,65 @@ func init() {
 
 var scannerMap sync.Map
 
+// FieldScanner returns a scanner for the field. NULL values are scanned
+// as zero values unless the field has the `scan_notnull` tag option,
+// in which case scanning NULL returns an error. The `notnull` option
+// only affects CREATE TABLE.
 func FieldScanner(dialect Dialect, field *Field) ScannerFunc {
+	fn := fieldScanner(dialect, field)
+	if fn != nil && field.Tag.HasOption("enum") {
+		fn = enumScanner(fn, field.IndirectType)
+	}
+	if fn != nil && field.Tag.HasOption("scan_notnull") {
+		return notNullScanner(fn, field.Name)
+	}
+	return fn
+}
+
+func fieldScanner(dialect Dialect, field *Field) ScannerFunc {
 	if field.Tag.HasOption("msgpack") {
 		return scanMsgpack
 	}
+	if unit, ok := unixTimeUnit(field); ok {
+		fn := unixTimeScanner(unit)
+		if field.IsPtr {
+			return PtrScanner(fn)
+		}
+		return fn
+	}
 	if field.Tag.HasOption("json_use_number") {
 		return scanJSONUseNumber
 	}
-	if field.StructField.Type.Kind() == reflect.Interface {
-		switch strings.ToUpper(field.UserSQLType) {
-		case sqltype.JSON, sqltype.JSONB:
+	switch strings.ToUpper(field.UserSQLType) {
+	case sqltype.JSON, sqltype.JSONB:
+		if field.StructField.Type.Kind() == reflect.Interface {
 			return scanJSONIntoInterface
 		}
+		// FieldAppender appends JSON, so values are not scanned with UnmarshalText.
+		if valueMarshaler(field.IndirectType) != noMarshaler {
+			return scanJSON
+		}
+	case "":
+		if valueMarshaler(field.IndirectType) != noMarshaler {
+			return scanJSON
+		}
 	}
 	return Scanner(field.StructField.Type)
 }
 
+// RegisterScanner registers a scanner for the type, which takes precedence
+// over the built-in scanners, for example, to scan numeric values into a decimal
+// type without a lossy conversion to float64:
+//
+//    schema.RegisterScanner((*Decimal)(nil), func(dest reflect.Value, src interface{}) error {
+//        ...
+//    })
+//
+// Scanners must be registered before the type is used in a model.
+func RegisterScanner(typ interface{}, fn ScannerFunc) {
+	scannerMap.Store(reflect.TypeOf(typ).Elem(), fn)
+}
+
 func Scanner(typ reflect.Type) ScannerFunc {
 	if v, ok := scannerMap.Load(typ); ok {
 		return v.(ScannerFunc)
@@ -117,6 +181,21 @@ func scanner(typ reflect.Type) ScannerFunc {
 		}
 	}
 
+	// Values are scanned with the unmarshaler only if they are appended
+	// with the matching marshaler, otherwise they are scanned as JSON.
+	switch valueMarshaler(typ) {
+	case textMarshaler:
+		if typ.Implements(textUnmarshalerType) {
+			return scanTextUnmarshaler
+		}
+		return addrScanner(scanTextUnmarshaler)
+	case binaryMarshaler:
+		if typ.Implements(binaryUnmarshalerType) {
+			return scanBinaryUnmarshaler
+		}
+		return addrScanner(scanBinaryUnmarshaler)
+	}
+
 	if typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8 {
 		return scanBytes
 	}
@@ -136,6 +215,18 @@ func scanBool(dest reflect.Value, src interface{}) error {
 		dest.SetBool(src != 0)
 		return nil
 	case []byte:
+		if len(src) == 1 {
+			// PostgreSQL bit(1) is returned as "0" or "1"
+			// and MySQL BIT(1) as a raw 0x00 or 0x01 byte.
+			switch src[0] {
+			case '0', 0:
+				dest.SetBool(false)
+				return nil
+			case '1', 1:
+				dest.SetBool(true)
+				return nil
+			}
+		}
 		f, err := strconv.ParseBool(internal.String(src))
 		if err != nil {
 			return err
@@ -320,9 +411,73 @@ func scanTime(dest reflect.Value, src interface{}) error {
 }
 
 func scanScanner(dest reflect.Value, src interface{}) error {
+	if dest.Kind() == reflect.Ptr && dest.IsNil() {
+		if src == nil {
+			return nil
+		}
+		if err := allocPtr(dest); err != nil {
+			return err
+		}
+	}
 	return dest.Interface().(sql.Scanner).Scan(src)
 }
 
+func scanTextUnmarshaler(dest reflect.Value, src interface{}) error {
+	if src == nil {
+		return scanNullUnmarshaler(dest)
+	}
+
+	b, err := toBytes(src)
+	if err != nil {
+		return err
+	}
+	if err := allocPtr(dest); err != nil {
+		return err
+	}
+	return dest.Interface().(encoding.TextUnmarshaler).UnmarshalText(b)
+}
+
+func scanBinaryUnmarshaler(dest reflect.Value, src interface{}) error {
+	if src == nil {
+		return scanNullUnmarshaler(dest)
+	}
+
+	b, err := toBytes(src)
+	if err != nil {
+		return err
+	}
+	if err := allocPtr(dest); err != nil {
+		return err
+	}
+	return dest.Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(b)
+}
+
+// allocPtr allocates the target of a nil pointer so methods with a pointer
+// receiver are not called on nil.
+func allocPtr(dest reflect.Value) error {
+	if dest.Kind() != reflect.Ptr || !dest.IsNil() {
+		return nil
+	}
+	if !dest.CanSet() {
+		return fmt.Errorf("bun: Scan(nil %s)", dest.Type())
+	}
+	dest.Set(reflect.New(dest.Type().Elem()))
+	return nil
+}
+
+// scanNullUnmarshaler resets the value instead of unmarshaling an empty input,
+// which most unmarshalers reject.
+func scanNullUnmarshaler(dest reflect.Value) error {
+	if dest.Kind() == reflect.Ptr {
+		if !dest.IsNil() {
+			dest = dest.Elem()
+			dest.Set(reflect.Zero(dest.Type()))
+		}
+		return nil
+	}
+	return scanNull(dest)
+}
+
 func scanMsgpack(dest reflect.Value, src interface{}) error {
 	if src == nil {
 		return scanNull(dest)
@@ -336,10 +491,57 @@ func scanMsgpack(dest reflect.Value, src interface{}) error {
 	dec := msgpack.GetDecoder()
 	defer msgpack.PutDecoder(dec)
 
+	if maxLen := int(atomic.LoadInt64(&msgpackMaxLen)); maxLen > 0 {
+		r := bytes.NewReader(b)
+		dec.Reset(r)
+		if err := checkMsgpackLen(dec, r, maxLen); err != nil {
+			return err
+		}
+	}
+
 	dec.Reset(bytes.NewReader(b))
 	return dec.DecodeValue(dest)
 }
 
+// checkMsgpackLen walks the msgpack value and verifies that arrays and maps
+// do not declare more elements than maxLen or than there are bytes left.
+func checkMsgpackLen(dec *msgpack.Decoder, r *bytes.Reader, maxLen int) error {
+	c, err := dec.PeekCode()
+	if err != nil {
+		return err
+	}
+
+	var n int
+	var numElem int
+	switch {
+	case msgpcode.IsFixedArray(c) || c == msgpcode.Array16 || c == msgpcode.Array32:
+		n, err = dec.DecodeArrayLen()
+		numElem = n
+	case msgpcode.IsFixedMap(c) || c == msgpcode.Map16 || c == msgpcode.Map32:
+		n, err = dec.DecodeMapLen()
+		numElem = 2 * n
+	default:
+		return dec.Skip()
+	}
+	if err != nil {
+		return err
+	}
+
+	if n > maxLen {
+		return fmt.Errorf("bun: msgpack length %d exceeds the limit of %d", n, maxLen)
+	}
+	if numElem > r.Len() {
+		return fmt.Errorf("bun: msgpack length %d exceeds the remaining %d bytes", n, r.Len())
+	}
+
+	for i := 0; i < numElem; i++ {
+		if err := checkMsgpackLen(dec, r, maxLen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func scanJSON(dest reflect.Value, src interface{}) error {
 	if src == nil {
 		return scanNull(dest)
@@ -410,6 +612,51 @@ func scanIPNet(dest reflect.Value, src interface{}) error {
 	return nil
 }
 
+var enumMap sync.Map
+
+// RegisterEnum registers values of a string-based enum type. Fields of that type
+// with the `enum` tag option return an error when an unknown value is scanned:
+//
+//    schema.RegisterEnum((*Status)(nil), "active", "disabled")
+func RegisterEnum(typ interface{}, values ...string) {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	enumMap.Store(reflect.TypeOf(typ).Elem(), set)
+}
+
+func enumScanner(fn ScannerFunc, typ reflect.Type) ScannerFunc {
+	return func(dest reflect.Value, src interface{}) error {
+		if err := fn(dest, src); err != nil {
+			return err
+		}
+
+		v := reflect.Indirect(dest)
+		if !v.IsValid() || v.Kind() != reflect.String || src == nil {
+			return nil
+		}
+
+		set, ok := enumMap.Load(typ)
+		if !ok {
+			return fmt.Errorf("bun: enum %s is not registered", typ)
+		}
+		if _, ok := set.(map[string]struct{})[v.String()]; !ok {
+			return fmt.Errorf("bun: %q is not a valid %s value", v.String(), typ)
+		}
+		return nil
+	}
+}
+
+func notNullScanner(fn ScannerFunc, column string) ScannerFunc {
+	return func(dest reflect.Value, src interface{}) error {
+		if src == nil {
+			return fmt.Errorf("bun: got NULL for the scan_notnull column %q", column)
+		}
+		return fn(dest, src)
+	}
+}
+
 func addrScanner(fn ScannerFunc) ScannerFunc {
 	return func(dest reflect.Value, src interface{}) error {
 		if !dest.CanAddr() {
@@ -441,13 +688,13 @@ func PtrScanner(fn ScannerFunc) ScannerFunc {
 			}
 
 			if !dest.IsNil() {
-				dest.Set(reflect.New(dest.Type().Elem()))
+				dest.Set(reflect.Zero(dest.Type()))
 			}
 			return nil
 		}
 
-		if dest.IsNil() {
-			dest.Set(reflect.New(dest.Type().Elem()))
+		if err := allocPtr(dest); err != nil {
+			return err
 		}
 
 		if dest.Kind() == reflect.Map {
@@ -514,3 +761,85 @@ func nilable(kind reflect.Kind) bool {
 func scanError(dest reflect.Type, src interface{}) error {
 	return fmt.Errorf("bun: can't scan %#v (%T) into %s", src, src, dest.String())
 }
+
+//------------------------------------------------------------------------------
+
+// unixTimeUnit returns the unit of time.Time fields with the `unixtime`
+// or `unixmilli` tag option, which are stored as integer Unix timestamps.
+func unixTimeUnit(field *Field) (time.Duration, bool) {
+	if field.IndirectType != timeType {
+		return 0, false
+	}
+	if field.Tag.HasOption("unixtime") {
+		return time.Second, true
+	}
+	if field.Tag.HasOption("unixmilli") {
+		return time.Millisecond, true
+	}
+	return 0, false
+}
+
+// unixTime converts the timestamp without going through nanoseconds,
+// which overflow int64 for dates after the year 2262.
+func unixTime(n int64, unit time.Duration) time.Time {
+	switch unit {
+	case time.Second:
+		return time.Unix(n, 0)
+	case time.Millisecond:
+		return time.UnixMilli(n)
+	case time.Microsecond:
+		return time.UnixMicro(n)
+	default:
+		return time.Unix(0, n*int64(unit))
+	}
+}
+
+func timeToUnix(tm time.Time, unit time.Duration) int64 {
+	switch unit {
+	case time.Second:
+		return tm.Unix()
+	case time.Millisecond:
+		return tm.UnixMilli()
+	case time.Microsecond:
+		return tm.UnixMicro()
+	default:
+		return tm.UnixNano() / int64(unit)
+	}
+}
+
+// unixTimeScanner scans an integer Unix timestamp into time.Time.
+// NULL and 0 are scanned as the zero time.
+func unixTimeScanner(unit time.Duration) ScannerFunc {
+	return func(dest reflect.Value, src interface{}) error {
+		var n int64
+		switch src := src.(type) {
+		case nil:
+		case int64:
+			n = src
+		case float64:
+			n = int64(src)
+		case []byte:
+			var err error
+			n, err = strconv.ParseInt(internal.String(src), 10, 64)
+			if err != nil {
+				return err
+			}
+		case string:
+			var err error
+			n, err = strconv.ParseInt(src, 10, 64)
+			if err != nil {
+				return err
+			}
+		default:
+			return scanError(dest.Type(), src)
+		}
+
+		destTime := dest.Addr().Interface().(*time.Time)
+		if n == 0 {
+			*destTime = time.Time{}
+		} else {
+			*destTime = unixTime(n, unit)
+		}
+		return nil
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/jinzhu/inflection"
 
+	"github.com/uptrace/bun/dialect/sqltype"
 	"github.com/uptrace/bun/internal"
 	"github.com/uptrace/bun/internal/tagparser"
 )
@@ -64,6 +65,9 @@ type Table struct {
 
 	SoftDeleteField       *Field
 	UpdateSoftDeleteField func(fv reflect.Value, tm time.Time) error
+	// SoftDeleteBool is set by the soft_delete:bool tag option. Deleted rows
+	// have the column set to TRUE and NULL is treated as not deleted.
+	SoftDeleteBool bool
 
 	allFields []*Field // read only
 
@@ -229,9 +233,14 @@ func (t *Table) addFields(typ reflect.Type, prefix string, index []int) {
 			// If field is an embedded struct, add each field of the embedded struct.
 			fieldType := indirectType(f.Type)
 			if fieldType.Kind() == reflect.Struct {
-				t.addFields(fieldType, "", withIndex(index, f.Index))
-
 				tag := tagparser.Parse(f.Tag.Get("bun"))
+
+				var embedPrefix string
+				if tag.HasOption("embed") {
+					embedPrefix = anonymousEmbedFieldPrefix(tag)
+				}
+				t.addFields(fieldType, embedPrefix, withIndex(index, f.Index))
+
 				if tag.HasOption("inherit") || tag.HasOption("extend") {
 					embeddedTable := t.dialect.Tables().Ref(fieldType)
 					t.TypeName = embeddedTable.TypeName
@@ -291,13 +300,13 @@ func (t *Table) processBaseModelField(f reflect.StructField) {
 func (t *Table) newField(f reflect.StructField, prefix string, index []int) *Field {
 	tag := tagparser.Parse(f.Tag.Get("bun"))
 
-	if prefix, ok := tag.Option("embed"); ok {
+	if tag.HasOption("embed") {
 		fieldType := indirectType(f.Type)
 		if fieldType.Kind() != reflect.Struct {
 			panic(fmt.Errorf("bun: embed %s.%s: got %s, wanted reflect.Struct",
 				t.TypeName, f.Name, fieldType.Kind()))
 		}
-		t.addFields(fieldType, prefix, withIndex(index, f.Index))
+		t.addFields(fieldType, embedFieldPrefix(tag), withIndex(index, f.Index))
 		return nil
 	}
 
@@ -353,8 +362,15 @@ func (t *Table) newField(f reflect.StructField, prefix string, index []int) *Fie
 		field.AutoIncrement = true
 		field.NullZero = true
 	}
-	if tag.HasOption("identity") {
+	if v, ok := tag.Options["identity"]; ok {
 		field.Identity = true
+		switch v[len(v)-1] {
+		case "", "by_default":
+		case "always":
+			field.IdentityAlways = true
+		default:
+			internal.Warn.Printf("%s.%s: unknown identity generation %q", t.TypeName, f.Name, v[len(v)-1])
+		}
 	}
 
 	if v, ok := tag.Options["unique"]; ok {
@@ -381,6 +397,8 @@ func (t *Table) newField(f reflect.StructField, prefix string, index []int) *Fie
 	}
 	if s, ok := field.Tag.Option("type"); ok {
 		field.UserSQLType = s
+	} else if _, ok := unixTimeUnit(field); ok {
+		field.UserSQLType = sqltype.BigInt
 	}
 	field.DiscoveredSQLType = DiscoverSQLType(field.IndirectType)
 	field.Append = FieldAppender(t.dialect, field)
@@ -400,9 +418,18 @@ func (t *Table) newField(f reflect.StructField, prefix string, index []int) *Fie
 		return nil
 	}
 
-	if _, ok := tag.Options["soft_delete"]; ok {
+	if v, ok := tag.Options["soft_delete"]; ok {
 		t.SoftDeleteField = field
-		t.UpdateSoftDeleteField = softDeleteFieldUpdater(field)
+		switch v[len(v)-1] {
+		case "":
+			t.UpdateSoftDeleteField = softDeleteFieldUpdater(field)
+		case "bool":
+			t.SoftDeleteBool = true
+			t.UpdateSoftDeleteField = softDeleteBoolUpdater(field)
+		default:
+			panic(fmt.Errorf("bun: %s.%s: unknown soft_delete style %q",
+				t.TypeName, field.GoName, v[len(v)-1]))
+		}
 	}
 
 	return field
@@ -861,6 +888,7 @@ func (t *Table) AppendNamedArg(
 	fmter Formatter, b []byte, name string, strct reflect.Value,
 ) ([]byte, bool) {
 	if field, ok := t.FieldMap[name]; ok {
+		fmter.recorder.inline()
 		return field.AppendValue(fmter, b, strct), true
 	}
 	return b, false
@@ -898,7 +926,11 @@ func isKnownFieldOption(name string) bool {
 		"composite",
 		"json_use_number",
 		"msgpack",
+		"unixtime",
+		"unixmilli",
+		"enum",
 		"notnull",
+		"scan_notnull",
 		"nullzero",
 		"default",
 		"unique",
@@ -964,6 +996,27 @@ func parseRelationJoin(join []string) ([]string, []string) {
 
 //------------------------------------------------------------------------------
 
+// embedFieldPrefix returns the column prefix for a struct field set with
+// `bun:"embed:org_"`. The tag name is not used as a prefix, so named fields
+// tagged with `bun:"org,embed"` keep the column names of the embedded struct.
+func embedFieldPrefix(tag tagparser.Tag) string {
+	prefix, _ := tag.Option("embed")
+	return prefix
+}
+
+// anonymousEmbedFieldPrefix returns the column prefix for an embedded struct,
+// which is either set with `bun:"embed:org_"` or derived from the tag name,
+// for example, `bun:"org,embed"` uses the "org_" prefix.
+func anonymousEmbedFieldPrefix(tag tagparser.Tag) string {
+	if prefix := embedFieldPrefix(tag); prefix != "" {
+		return prefix
+	}
+	if tag.Name != "" {
+		return tag.Name + "_"
+	}
+	return ""
+}
+
 func softDeleteFieldUpdater(field *Field) func(fv reflect.Value, tm time.Time) error {
 	typ := field.StructField.Type
 
@@ -1021,6 +1074,25 @@ func softDeleteFieldUpdater(field *Field) func(fv reflect.Value, tm time.Time) e
 	return softDeleteFieldUpdaterFallback(field)
 }
 
+func softDeleteBoolUpdater(field *Field) func(fv reflect.Value, tm time.Time) error {
+	switch typ := field.StructField.Type; {
+	case typ.Kind() == reflect.Bool:
+		return func(fv reflect.Value, tm time.Time) error {
+			fv.SetBool(true)
+			return nil
+		}
+	case typ == nullBoolType:
+		return func(fv reflect.Value, tm time.Time) error {
+			ptr := fv.Addr().Interface().(*sql.NullBool)
+			*ptr = sql.NullBool{Bool: true, Valid: true}
+			return nil
+		}
+	}
+	return func(fv reflect.Value, tm time.Time) error {
+		return field.ScanWithCheck(fv, true)
+	}
+}
+
 func softDeleteFieldUpdaterFallback(field *Field) func(fv reflect.Value, tm time.Time) error {
 	return func(fv reflect.Value, tm time.Time) error {
 		return field.ScanWithCheck(fv, tm)
@@ -1,6 +1,9 @@
 package bun
 
-import "reflect"
+import (
+	"reflect"
+	"strings"
+)
 
 func indirect(v reflect.Value) reflect.Value {
 	switch v.Kind() {
@@ -112,3 +115,71 @@ func makeSliceNextElemFunc(v reflect.Value) func() reflect.Value {
 		return v.Index(l)
 	}
 }
+
+// prettyClauses are the clauses that prettyQuery starts on a new line.
+// Longer clauses come first so "LEFT JOIN" is not split before "JOIN".
+var prettyClauses = []string{
+	"LEFT JOIN", "RIGHT JOIN", "FULL JOIN", "INNER JOIN", "CROSS JOIN", "JOIN",
+	"FROM", "WHERE", "GROUP BY", "HAVING", "WINDOW", "ORDER BY",
+	"LIMIT", "OFFSET", "FETCH", "FOR",
+	"UNION", "INTERSECT", "EXCEPT",
+	"SET", "VALUES", "OUTPUT", "ON CONFLICT", "ON DUPLICATE KEY", "RETURNING",
+}
+
+// prettyQuery inserts a newline before every top-level clause of the query.
+// Quoted strings and identifiers and parenthesized subqueries are left as is.
+func prettyQuery(query string) string {
+	b := make([]byte, 0, len(query)+32)
+	var depth int
+
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; c {
+		case '\'', '"', '`':
+			j := i + 1
+			for j < len(query) {
+				if query[j] == c {
+					if j+1 < len(query) && query[j+1] == c {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			if j == len(query) {
+				j--
+			}
+			b = append(b, query[i:j+1]...)
+			i = j
+			continue
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ' ':
+			if depth == 0 {
+				if clause := prettyClause(query[i+1:]); clause != "" {
+					b = append(b, '\n')
+					b = append(b, clause...)
+					i += len(clause)
+					continue
+				}
+			}
+		}
+		b = append(b, query[i])
+	}
+
+	return string(b)
+}
+
+func prettyClause(s string) string {
+	for _, clause := range prettyClauses {
+		if !strings.HasPrefix(s, clause) {
+			continue
+		}
+		if len(s) == len(clause) || s[len(clause)] == ' ' {
+			return clause
+		}
+	}
+	return ""
+}